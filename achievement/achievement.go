@@ -0,0 +1,133 @@
+package achievement
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/luqmanarifin/kentang/model"
+)
+
+type Badge struct {
+	Name        string
+	Title       string
+	Description string
+}
+
+var (
+	Milestones = []int{10, 100, 1000}
+	Streaks    = []int{3, 7, 30, 100}
+
+	FirstOfTheDay = Badge{"first-of-the-day", "Early Bird", "first count of the day"}
+	NightOwl      = Badge{"night-owl", "Night Owl", "counted between midnight and 4 AM"}
+)
+
+func Milestone(n int) Badge {
+	return Badge{fmt.Sprintf("milestone-%d", n), fmt.Sprintf("%dth", n), fmt.Sprintf("counted %d times", n)}
+}
+
+func Streak(n int) Badge {
+	return Badge{fmt.Sprintf("streak-%d", n), fmt.Sprintf("%d Day Streak", n), fmt.Sprintf("counted %d days in a row", n)}
+}
+
+// All lists every badge that can be unlocked, in display order.
+func All() []Badge {
+	var badges []Badge
+	for _, n := range Milestones {
+		badges = append(badges, Milestone(n))
+	}
+	for _, n := range Streaks {
+		badges = append(badges, Streak(n))
+	}
+	return append(badges, FirstOfTheDay, NightOwl)
+}
+
+func Find(name string) (Badge, bool) {
+	for _, b := range All() {
+		if b.Name == name {
+			return b, true
+		}
+	}
+	return Badge{}, false
+}
+
+// Progress is what a keyword has achieved right after being counted.
+type Progress struct {
+	Count      int
+	Streak     int
+	FirstOfDay bool
+	At         time.Time
+}
+
+// Compute builds the progress of a keyword from how many times it was
+// counted and its entries since StreakFrom, the latest one included. Days are
+// cut in loc.
+func Compute(count int, recent []model.Entry, firstOfDay bool, at time.Time, loc *time.Location) Progress {
+	return Progress{
+		Count:      count,
+		Streak:     DailyStreak(recent, at, loc),
+		FirstOfDay: firstOfDay,
+		At:         at.In(loc),
+	}
+}
+
+// StreakFrom returns the start of the day one day further back than the
+// longest streak badge, which is enough history to tell every streak badge
+// apart from one reached before.
+func StreakFrom(at time.Time, loc *time.Location) time.Time {
+	day := at.In(loc)
+	longest := Streaks[len(Streaks)-1]
+	return time.Date(day.Year(), day.Month(), day.Day()-longest, 0, 0, 0, 0, loc)
+}
+
+// DailyStreak counts the consecutive days, ending on the day of at, that have
+// at least one entry.
+func DailyStreak(entries []model.Entry, at time.Time, loc *time.Location) int {
+	days := make(map[string]bool)
+	for _, e := range entries {
+		days[e.Timestamp.In(loc).Format("2006-01-02")] = true
+	}
+	streak := 0
+	for day := at.In(loc); days[day.Format("2006-01-02")]; day = day.AddDate(0, 0, -1) {
+		streak++
+	}
+	return streak
+}
+
+// Reached tells whether the latest count is the one that earned b, rather
+// than b having been earned by an earlier count.
+func (p Progress) Reached(b Badge) bool {
+	for _, n := range Milestones {
+		if b == Milestone(n) {
+			return p.Count == n
+		}
+	}
+	for _, n := range Streaks {
+		if b == Streak(n) {
+			return p.Streak == n
+		}
+	}
+	return true
+}
+
+// Unlocked returns the badges earned by p, which may include ones unlocked
+// before.
+func (p Progress) Unlocked() []Badge {
+	var badges []Badge
+	for _, n := range Milestones {
+		if p.Count >= n {
+			badges = append(badges, Milestone(n))
+		}
+	}
+	for _, n := range Streaks {
+		if p.Streak >= n {
+			badges = append(badges, Streak(n))
+		}
+	}
+	if p.FirstOfDay {
+		badges = append(badges, FirstOfTheDay)
+	}
+	if p.At.Hour() < 4 {
+		badges = append(badges, NightOwl)
+	}
+	return badges
+}
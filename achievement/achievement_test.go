@@ -0,0 +1,74 @@
+package achievement
+
+import (
+	"testing"
+	"time"
+
+	"github.com/luqmanarifin/kentang/model"
+)
+
+func TestDailyStreak(t *testing.T) {
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	at := time.Date(2026, 9, 17, 1, 0, 0, 0, loc)
+	entries := []model.Entry{
+		{Timestamp: time.Date(2026, 9, 13, 12, 0, 0, 0, loc)},
+		{Timestamp: time.Date(2026, 9, 15, 12, 0, 0, 0, loc)},
+		// 16 Sep 23:30 in Jakarta, still 16 Sep in UTC
+		{Timestamp: time.Date(2026, 9, 16, 16, 30, 0, 0, time.UTC)},
+		{Timestamp: at},
+	}
+	if got := DailyStreak(entries, at, loc); got != 3 {
+		t.Errorf("got streak %d, want 3", got)
+	}
+	if got := DailyStreak(entries, at.AddDate(0, 0, 1), loc); got != 0 {
+		t.Errorf("got streak %d after a missed day, want 0", got)
+	}
+}
+
+func TestUnlocked(t *testing.T) {
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	p := Progress{Count: 10, Streak: 7, At: time.Date(2026, 9, 17, 2, 0, 0, 0, loc)}
+
+	got := make(map[string]bool)
+	for _, b := range p.Unlocked() {
+		got[b.Name] = true
+	}
+	for _, name := range []string{"milestone-10", "streak-3", "streak-7", "night-owl"} {
+		if !got[name] {
+			t.Errorf("expected %s to be unlocked", name)
+		}
+	}
+	for _, name := range []string{"milestone-100", "streak-30", "first-of-the-day"} {
+		if got[name] {
+			t.Errorf("expected %s to stay locked", name)
+		}
+	}
+}
+
+func TestReached(t *testing.T) {
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	at := time.Date(2026, 9, 17, 12, 0, 0, 0, loc)
+	p := Progress{Count: 10, Streak: 8, At: at}
+	if !p.Reached(Milestone(10)) {
+		t.Error("the 10th count didn't reach milestone-10")
+	}
+	if p.Reached(Streak(7)) {
+		t.Error("an 8 day streak reached streak-7 again")
+	}
+
+	// a streak longer than the longest badge isn't mistaken for reaching it
+	longest := Streaks[len(Streaks)-1]
+	var entries []model.Entry
+	for day := 0; day < longest+10; day++ {
+		entries = append(entries, model.Entry{Timestamp: at.AddDate(0, 0, -day)})
+	}
+	var recent []model.Entry
+	for _, e := range entries {
+		if !e.Timestamp.Before(StreakFrom(at, loc)) {
+			recent = append(recent, e)
+		}
+	}
+	if p := Compute(len(entries), recent, false, at, loc); p.Reached(Streak(longest)) {
+		t.Errorf("a %d day streak reached streak-%d", longest+10, longest)
+	}
+}
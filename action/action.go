@@ -0,0 +1,92 @@
+package action
+
+import (
+	"crypto/hmac"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/luqmanarifin/kentang/util"
+)
+
+// MaxDataLength is the longest postback data LINE accepts.
+const MaxDataLength = 300
+
+var (
+	ErrInvalidSignature = errors.New("invalid action signature")
+	ErrExpired          = errors.New("action expired")
+	ErrTooLong          = errors.New("action too long")
+)
+
+// Action is a command carried by a postback, e.g. highscore week.
+type Action struct {
+	Name     string
+	Args     []string
+	IssuedAt time.Time
+}
+
+func New(name string, args ...string) Action {
+	return Action{Name: name, Args: args, IssuedAt: time.Now()}
+}
+
+// Encode packs a as name|arg|...|issued|signature. The signature covers
+// scope too, so an action issued in one chat can't be replayed in another.
+func Encode(secret, scope string, a Action) (string, error) {
+	fields := []string{url.QueryEscape(a.Name)}
+	for _, arg := range a.Args {
+		fields = append(fields, url.QueryEscape(arg))
+	}
+	fields = append(fields, strconv.FormatInt(a.IssuedAt.Unix(), 36))
+	payload := strings.Join(fields, "|")
+	data := payload + "|" + sign(secret, scope, payload)
+	if len(data) > MaxDataLength {
+		return "", ErrTooLong
+	}
+	return data, nil
+}
+
+// Decode unpacks data made by Encode, rejecting it when the signature doesn't
+// match or when it is older than maxAge.
+func Decode(secret, scope, data string, maxAge time.Duration) (Action, error) {
+	i := strings.LastIndex(data, "|")
+	if i < 0 {
+		return Action{}, ErrInvalidSignature
+	}
+	payload, sig := data[:i], data[i+1:]
+	if !hmac.Equal([]byte(sig), []byte(sign(secret, scope, payload))) {
+		return Action{}, ErrInvalidSignature
+	}
+
+	fields := strings.Split(payload, "|")
+	if len(fields) < 2 {
+		return Action{}, ErrInvalidSignature
+	}
+	issued, err := strconv.ParseInt(fields[len(fields)-1], 36, 64)
+	if err != nil {
+		return Action{}, ErrInvalidSignature
+	}
+	a := Action{IssuedAt: time.Unix(issued, 0)}
+	if time.Since(a.IssuedAt) > maxAge {
+		return Action{}, ErrExpired
+	}
+
+	for i, field := range fields[:len(fields)-1] {
+		value, err := url.QueryUnescape(field)
+		if err != nil {
+			return Action{}, err
+		}
+		if i == 0 {
+			a.Name = value
+		} else {
+			a.Args = append(a.Args, value)
+		}
+	}
+	return a, nil
+}
+
+// sign keeps 16 characters (96 bits) of the signature, plenty for a postback.
+func sign(secret, scope, payload string) string {
+	return util.Sign(secret, scope+"|"+payload)[:16]
+}
@@ -0,0 +1,37 @@
+package action
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	data, err := Encode("secret", "group", New("remove", "kentang|goreng"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := Decode("secret", "group", data, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Name != "remove" || len(a.Args) != 1 || a.Args[0] != "kentang|goreng" {
+		t.Errorf("got %+v", a)
+	}
+}
+
+func TestDecodeRejects(t *testing.T) {
+	data, _ := Encode("secret", "group", New("reset"))
+	if _, err := Decode("secret", "other group", data, time.Minute); err != ErrInvalidSignature {
+		t.Errorf("another scope: got %v", err)
+	}
+	if _, err := Decode("secret", "group", "highscore"+data[5:], time.Minute); err != ErrInvalidSignature {
+		t.Errorf("tampered: got %v", err)
+	}
+
+	old := New("reset")
+	old.IssuedAt = time.Now().Add(-time.Hour)
+	data, _ = Encode("secret", "group", old)
+	if _, err := Decode("secret", "group", data, time.Minute); err != ErrExpired {
+		t.Errorf("expired: got %v", err)
+	}
+}
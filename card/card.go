@@ -0,0 +1,179 @@
+package card
+
+import (
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/line/line-bot-sdk-go/v7/linebot"
+)
+
+const (
+	// MaxAltTextLength is the longest alt text LINE accepts.
+	MaxAltTextLength = 400
+
+	rowsPerBubble = 10
+	maxBubbles    = 12
+)
+
+var medals = map[int]string{1: "🥇", 2: "🥈", 3: "🥉"}
+
+// Row is one line of a card, e.g. a keyword and its count.
+type Row struct {
+	Rank     int
+	Label    string
+	Detail   string
+	Value    string
+	ImageURL string
+}
+
+type Card struct {
+	Title string
+	Rows  []Row
+}
+
+// Flex renders c as a bubble, or as a carousel of bubbles when it has too many
+// rows for one. Rows that don't fit in a carousel are summed up in a last
+// row. altText is shown where flex messages can't be, like notifications.
+func Flex(c Card, altText string) *linebot.FlexMessage {
+	rows := c.Rows
+	if capacity := rowsPerBubble * maxBubbles; len(rows) > capacity {
+		more := len(rows) - (capacity - 1)
+		rows = append(rows[:capacity-1:capacity-1], Row{Label: "…and " + strconv.Itoa(more) + " more"})
+	}
+
+	var bubbles []*linebot.BubbleContainer
+	for start := 0; start < len(rows); start += rowsPerBubble {
+		end := start + rowsPerBubble
+		if end > len(rows) {
+			end = len(rows)
+		}
+		bubbles = append(bubbles, bubble(c.Title, rows[start:end]))
+	}
+	if len(bubbles) == 0 {
+		bubbles = append(bubbles, bubble(c.Title, nil))
+	}
+
+	var contents linebot.FlexContainer = bubbles[0]
+	if len(bubbles) > 1 {
+		contents = &linebot.CarouselContainer{
+			Type:     linebot.FlexContainerTypeCarousel,
+			Contents: bubbles,
+		}
+	}
+	return linebot.NewFlexMessage(AltText(altText), contents)
+}
+
+// AltText cuts text down to what LINE accepts as alt text.
+func AltText(text string) string {
+	if utf8.RuneCountInString(text) <= MaxAltTextLength {
+		return text
+	}
+	return string([]rune(text)[:MaxAltTextLength-1]) + "…"
+}
+
+func bubble(title string, rows []Row) *linebot.BubbleContainer {
+	body := []linebot.FlexComponent{
+		&linebot.TextComponent{
+			Type:   linebot.FlexComponentTypeText,
+			Text:   title,
+			Size:   linebot.FlexTextSizeTypeLg,
+			Weight: linebot.FlexTextWeightTypeBold,
+			Wrap:   true,
+		},
+		&linebot.SeparatorComponent{
+			Type:   linebot.FlexComponentTypeSeparator,
+			Margin: linebot.FlexComponentMarginTypeMd,
+		},
+	}
+	for _, r := range rows {
+		body = append(body, row(r))
+	}
+
+	return &linebot.BubbleContainer{
+		Type: linebot.FlexContainerTypeBubble,
+		Size: linebot.FlexBubbleSizeTypeMega,
+		Body: &linebot.BoxComponent{
+			Type:     linebot.FlexComponentTypeBox,
+			Layout:   linebot.FlexBoxLayoutTypeVertical,
+			Spacing:  linebot.FlexComponentSpacingTypeSm,
+			Contents: body,
+		},
+	}
+}
+
+func row(r Row) *linebot.BoxComponent {
+	var contents []linebot.FlexComponent
+	if r.Rank > 0 {
+		rank, ok := medals[r.Rank]
+		if !ok {
+			rank = strconv.Itoa(r.Rank) + "."
+		}
+		contents = append(contents, &linebot.TextComponent{
+			Type:    linebot.FlexComponentTypeText,
+			Text:    rank,
+			Flex:    intPtr(0),
+			Size:    linebot.FlexTextSizeTypeSm,
+			Gravity: linebot.FlexComponentGravityTypeCenter,
+		})
+	}
+	if r.ImageURL != "" {
+		contents = append(contents, &linebot.ImageComponent{
+			Type:        linebot.FlexComponentTypeImage,
+			URL:         r.ImageURL,
+			Flex:        intPtr(0),
+			Margin:      linebot.FlexComponentMarginTypeSm,
+			Size:        linebot.FlexImageSizeTypeXxs,
+			AspectRatio: linebot.FlexImageAspectRatioType1to1,
+			AspectMode:  linebot.FlexImageAspectModeTypeCover,
+		})
+	}
+
+	label := []linebot.FlexComponent{
+		&linebot.TextComponent{
+			Type:   linebot.FlexComponentTypeText,
+			Text:   r.Label,
+			Size:   linebot.FlexTextSizeTypeSm,
+			Weight: linebot.FlexTextWeightTypeBold,
+			Wrap:   true,
+		},
+	}
+	if r.Detail != "" {
+		label = append(label, &linebot.TextComponent{
+			Type:  linebot.FlexComponentTypeText,
+			Text:  r.Detail,
+			Size:  linebot.FlexTextSizeTypeXs,
+			Color: "#888888",
+			Wrap:  true,
+		})
+	}
+	contents = append(contents, &linebot.BoxComponent{
+		Type:     linebot.FlexComponentTypeBox,
+		Layout:   linebot.FlexBoxLayoutTypeVertical,
+		Flex:     intPtr(1),
+		Margin:   linebot.FlexComponentMarginTypeMd,
+		Contents: label,
+	})
+
+	if r.Value != "" {
+		contents = append(contents, &linebot.TextComponent{
+			Type:    linebot.FlexComponentTypeText,
+			Text:    r.Value,
+			Flex:    intPtr(0),
+			Size:    linebot.FlexTextSizeTypeSm,
+			Weight:  linebot.FlexTextWeightTypeBold,
+			Align:   linebot.FlexComponentAlignTypeEnd,
+			Gravity: linebot.FlexComponentGravityTypeCenter,
+		})
+	}
+
+	return &linebot.BoxComponent{
+		Type:     linebot.FlexComponentTypeBox,
+		Layout:   linebot.FlexBoxLayoutTypeHorizontal,
+		Margin:   linebot.FlexComponentMarginTypeMd,
+		Contents: contents,
+	}
+}
+
+func intPtr(v int) *int {
+	return &v
+}
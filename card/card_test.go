@@ -0,0 +1,58 @@
+package card
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/line/line-bot-sdk-go/v7/linebot"
+)
+
+func TestFlexSingleBubble(t *testing.T) {
+	msg := Flex(Card{Title: "Highscore", Rows: []Row{{Rank: 1, Label: "kentang", Value: "12"}}}, "Highscore:\nkentang : 12")
+	if _, ok := msg.Contents.(*linebot.BubbleContainer); !ok {
+		t.Fatalf("expected a bubble, got %T", msg.Contents)
+	}
+	if msg.AltText != "Highscore:\nkentang : 12" {
+		t.Errorf("got alt text %q", msg.AltText)
+	}
+}
+
+func TestFlexCarousel(t *testing.T) {
+	var rows []Row
+	for i := 1; i <= 25; i++ {
+		rows = append(rows, Row{Rank: i, Label: "k" + strconv.Itoa(i)})
+	}
+	msg := Flex(Card{Title: "Keywords", Rows: rows}, strings.Repeat("x", 1000))
+	carousel, ok := msg.Contents.(*linebot.CarouselContainer)
+	if !ok {
+		t.Fatalf("expected a carousel, got %T", msg.Contents)
+	}
+	if len(carousel.Contents) != 3 {
+		t.Errorf("got %d bubbles, want 3", len(carousel.Contents))
+	}
+	if n := utf8.RuneCountInString(msg.AltText); n > MaxAltTextLength {
+		t.Errorf("alt text has %d characters", n)
+	}
+}
+
+func TestFlexTooManyRows(t *testing.T) {
+	var rows []Row
+	for i := 1; i <= 130; i++ {
+		rows = append(rows, Row{Rank: i, Label: "k" + strconv.Itoa(i)})
+	}
+	msg := Flex(Card{Title: "Keywords", Rows: rows}, "Keywords")
+	carousel := msg.Contents.(*linebot.CarouselContainer)
+	if len(carousel.Contents) != maxBubbles {
+		t.Fatalf("got %d bubbles, want %d", len(carousel.Contents), maxBubbles)
+	}
+	last := carousel.Contents[maxBubbles-1].Body.Contents
+	more := last[len(last)-1].(*linebot.BoxComponent).Contents[0].(*linebot.BoxComponent).Contents[0].(*linebot.TextComponent)
+	if more.Text != "…and 11 more" {
+		t.Errorf("got last row %q, want the rows left out", more.Text)
+	}
+	if len(rows) != 130 || rows[119].Label != "k120" {
+		t.Error("the card's rows were changed")
+	}
+}
@@ -0,0 +1,94 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+const (
+	PlatformLINE     = "line"
+	PlatformTelegram = "telegram"
+	PlatformSlack    = "slack"
+	PlatformDiscord  = "discord"
+)
+
+// ErrInvalidSignature is returned when a webhook request isn't signed by the
+// platform.
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// Platform sends text messages to the conversations of one chat service.
+type Platform interface {
+	Name() string
+	Reply(event *Event, messages ...string) error
+	Push(ctx context.Context, to string, messages ...string) error
+}
+
+// Event is a text message received from a chat platform.
+//
+// Source is the conversation the message was sent in and UserID its sender.
+// IDs of platforms other than LINE are prefixed with the platform name, so
+// they never clash with LINE's and data kept before other platforms existed
+// stays valid.
+//
+// EventID is the platform's ID of the event, which stays the same when the
+// platform delivers it again. It is empty when the platform has none.
+type Event struct {
+	Platform   Platform
+	Source     string
+	UserID     string
+	UserName   string
+	ReplyToken string
+	Text       string
+	EventID    string
+
+	ctx context.Context
+}
+
+// Context is what the event is handled in, e.g. the fields logged with it.
+func (e *Event) Context() context.Context {
+	if e.ctx == nil {
+		return context.Background()
+	}
+	return e.ctx
+}
+
+// WithContext returns a copy of e handled in ctx.
+func (e *Event) WithContext(ctx context.Context) *Event {
+	copied := *e
+	copied.ctx = ctx
+	return &copied
+}
+
+func (e *Event) Reply(messages ...string) error {
+	return e.Platform.Reply(e, messages...)
+}
+
+// Is tells whether the event came from the named platform.
+func (e *Event) Is(name string) bool {
+	return e.Platform != nil && e.Platform.Name() == name
+}
+
+// ID prefixes id with the platform name.
+func ID(platform, id string) string {
+	if platform == PlatformLINE {
+		return id
+	}
+	return platform + ":" + id
+}
+
+// PlatformOf returns the platform a source or user ID belongs to.
+func PlatformOf(id string) string {
+	if i := strings.Index(id, ":"); i > 0 {
+		return id[:i]
+	}
+	return PlatformLINE
+}
+
+// LocalID strips the platform prefix of id.
+func LocalID(id string) string {
+	if i := strings.Index(id, ":"); i > 0 {
+		return id[i+1:]
+	}
+	return id
+}
@@ -0,0 +1,184 @@
+package chat
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/luqmanarifin/kentang/render"
+)
+
+const (
+	discordEndpoint      = "https://discord.com/api/v10"
+	discordMaxTextLength = 2000
+
+	discordPing               = 1
+	discordApplicationCommand = 2
+
+	// DiscordPong answers Discord's ping.
+	DiscordPong = `{"type":1}`
+	// DiscordDeferred acknowledges a command whose replies follow later.
+	DiscordDeferred = `{"type":5}`
+)
+
+// Discord receives slash commands from a Discord interactions endpoint and
+// sends messages back through the HTTP API.
+type Discord struct {
+	applicationID string
+	publicKey     ed25519.PublicKey
+	botToken      string
+	endpoint      string
+	client        *http.Client
+}
+
+// NewDiscord makes a Discord adapter. publicKey is the hex encoded key of the
+// application, verifying requests from Discord. endpoint defaults to the HTTP
+// API when empty.
+func NewDiscord(applicationID, publicKey, botToken, endpoint string) (*Discord, error) {
+	key, err := hex.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid discord public key")
+	}
+	if endpoint == "" {
+		endpoint = discordEndpoint
+	}
+	return &Discord{
+		applicationID: applicationID,
+		publicKey:     ed25519.PublicKey(key),
+		botToken:      botToken,
+		endpoint:      strings.TrimSuffix(endpoint, "/"),
+		client:        newClient("discord"),
+	}, nil
+}
+
+type discordInteraction struct {
+	ID        string `json:"id"`
+	Type      int    `json:"type"`
+	Token     string `json:"token"`
+	ChannelID string `json:"channel_id"`
+	Member    *struct {
+		Nick string      `json:"nick"`
+		User discordUser `json:"user"`
+	} `json:"member"`
+	User *discordUser `json:"user"`
+	Data struct {
+		Name    string `json:"name"`
+		Options []struct {
+			Value interface{} `json:"value"`
+		} `json:"options"`
+	} `json:"data"`
+}
+
+type discordUser struct {
+	ID         string `json:"id"`
+	Username   string `json:"username"`
+	GlobalName string `json:"global_name"`
+}
+
+func (d *Discord) Name() string {
+	return PlatformDiscord
+}
+
+// ParseRequest reads an interaction. It returns ping as true for Discord's
+// pings, which must be answered with DiscordPong, and a nil event for
+// interactions that aren't commands.
+func (d *Discord) ParseRequest(r *http.Request) (event *Event, ping bool, err error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, false, err
+	}
+	signature, err := hex.DecodeString(r.Header.Get("X-Signature-Ed25519"))
+	if err != nil {
+		return nil, false, ErrInvalidSignature
+	}
+	message := append([]byte(r.Header.Get("X-Signature-Timestamp")), body...)
+	if !ed25519.Verify(d.publicKey, message, signature) {
+		return nil, false, ErrInvalidSignature
+	}
+
+	var interaction discordInteraction
+	if err := json.Unmarshal(body, &interaction); err != nil {
+		return nil, false, err
+	}
+	switch interaction.Type {
+	case discordPing:
+		return nil, true, nil
+	case discordApplicationCommand:
+	default:
+		return nil, false, nil
+	}
+
+	event = &Event{
+		Platform:   d,
+		Source:     ID(PlatformDiscord, interaction.ChannelID),
+		ReplyToken: interaction.Token,
+		Text:       discordCommand(interaction),
+		EventID:    ID(PlatformDiscord, interaction.ID),
+	}
+	user, name := interaction.User, ""
+	if interaction.Member != nil {
+		user, name = &interaction.Member.User, interaction.Member.Nick
+	}
+	if user != nil {
+		event.UserID = ID(PlatformDiscord, user.ID)
+		if name == "" {
+			name = user.GlobalName
+		}
+		if name == "" {
+			name = user.Username
+		}
+		event.UserName = name
+	}
+	return event, false, nil
+}
+
+// discordCommand turns a slash command into a bot command. "/kentang" takes
+// the whole command as its option, other commands are named after the bot
+// command they run, e.g. "/add foo bar".
+func discordCommand(interaction discordInteraction) string {
+	var words []string
+	if interaction.Data.Name != "kentang" {
+		words = append(words, interaction.Data.Name)
+	}
+	for _, option := range interaction.Data.Options {
+		words = append(words, fmt.Sprint(option.Value))
+	}
+	return strings.Join(words, " ")
+}
+
+// Reply sends messages as follow-ups of the interaction, which has to be
+// acknowledged with DiscordDeferred first.
+func (d *Discord) Reply(event *Event, messages ...string) error {
+	u := d.endpoint + "/webhooks/" + d.applicationID + "/" + event.ReplyToken
+	return d.send(event.Context(), u, "", messages...)
+}
+
+func (d *Discord) Push(ctx context.Context, to string, messages ...string) error {
+	u := d.endpoint + "/channels/" + LocalID(to) + "/messages"
+	return d.send(ctx, u, "Bot "+d.botToken, messages...)
+}
+
+func (d *Discord) send(ctx context.Context, u, authorization string, messages ...string) error {
+	for _, message := range messages {
+		body, status, err := postJSON(ctx, d.client, u, authorization, map[string]string{
+			"content": render.Truncate(message, discordMaxTextLength),
+		})
+		if err != nil {
+			return fmt.Errorf("discord: %v", err)
+		}
+		if status < 200 || status >= 300 {
+			var result struct {
+				Message string `json:"message"`
+			}
+			json.Unmarshal(body, &result)
+			return fmt.Errorf("discord: status %d %s", status, result.Message)
+		}
+	}
+	return nil
+}
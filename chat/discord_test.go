@@ -0,0 +1,67 @@
+package chat
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func discordRequest(key ed25519.PrivateKey, body string) *http.Request {
+	timestamp := "1700000000"
+	r := httptest.NewRequest("POST", "/discord", strings.NewReader(body))
+	r.Header.Set("X-Signature-Timestamp", timestamp)
+	r.Header.Set("X-Signature-Ed25519", hex.EncodeToString(ed25519.Sign(key, []byte(timestamp+body))))
+	return r
+}
+
+func TestDiscordParseRequest(t *testing.T) {
+	public, private, _ := ed25519.GenerateKey(nil)
+	_, other, _ := ed25519.GenerateKey(nil)
+	d, err := NewDiscord("app", hex.EncodeToString(public), "token", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ping, err := d.ParseRequest(discordRequest(private, `{"type":1}`)); err != nil || !ping {
+		t.Errorf("ping: got %v, %v", ping, err)
+	}
+	if _, _, err := d.ParseRequest(discordRequest(other, `{"type":1}`)); err != ErrInvalidSignature {
+		t.Errorf("wrong key: got %v", err)
+	}
+
+	body := `{"type":2,"token":"tok","channel_id":"42","member":{"user":{"id":"7","username":"niki"}},"data":{"name":"add","options":[{"value":"telat"},{"value":"terlambat"}]}}`
+	event, _, err := d.ParseRequest(discordRequest(private, body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Source != "discord:42" || event.UserID != "discord:7" || event.UserName != "niki" {
+		t.Errorf("got %+v", event)
+	}
+	if event.Text != "add telat terlambat" {
+		t.Errorf("text: got %q", event.Text)
+	}
+}
+
+func TestDiscordReply(t *testing.T) {
+	var path string
+	var got map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer server.Close()
+
+	public, _, _ := ed25519.GenerateKey(nil)
+	d, _ := NewDiscord("app", hex.EncodeToString(public), "token", server.URL)
+	event := &Event{Platform: d, Source: "discord:42", ReplyToken: "tok"}
+	if err := event.Reply("list"); err != nil {
+		t.Fatal(err)
+	}
+	if path != "/webhooks/app/tok" || got["content"] != "list" {
+		t.Errorf("got %s %v", path, got)
+	}
+}
@@ -0,0 +1,53 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/luqmanarifin/kentang/tracing"
+)
+
+// postJSON posts params as JSON to u and returns the response body. Errors
+// leave u out since platforms put tokens in their URLs.
+func postJSON(ctx context.Context, client *http.Client, u, authorization string, params interface{}) ([]byte, int, error) {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return nil, 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", u, bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid request URL")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	return respBody, resp.StatusCode, err
+}
+
+// newClient makes the client a platform calls its API with. Every call is
+// traced in a span named after the platform.
+func newClient(platform string) *http.Client {
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: tracing.Transport(http.DefaultTransport, func(*http.Request) string {
+			return platform
+		}),
+	}
+}
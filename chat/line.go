@@ -0,0 +1,36 @@
+package chat
+
+import (
+	"context"
+
+	"github.com/line/line-bot-sdk-go/v7/linebot"
+	"github.com/luqmanarifin/kentang/render"
+)
+
+// Line sends text messages through the LINE Messaging API.
+type Line struct {
+	Client *linebot.Client
+}
+
+func (l *Line) Name() string {
+	return PlatformLINE
+}
+
+func (l *Line) Reply(event *Event, messages ...string) error {
+	_, err := l.Client.ReplyMessage(event.ReplyToken, TextMessages(messages...)...).WithContext(event.Context()).Do()
+	return err
+}
+
+func (l *Line) Push(ctx context.Context, to string, messages ...string) error {
+	_, err := l.Client.PushMessage(to, TextMessages(messages...)...).WithContext(ctx).Do()
+	return err
+}
+
+// TextMessages turns messages into LINE text messages short enough to send.
+func TextMessages(messages ...string) []linebot.SendingMessage {
+	var lineMessages []linebot.SendingMessage
+	for _, message := range messages {
+		lineMessages = append(lineMessages, linebot.NewTextMessage(render.Truncate(message, render.MaxTextLength)))
+	}
+	return lineMessages
+}
@@ -0,0 +1,190 @@
+package chat
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/luqmanarifin/kentang/render"
+)
+
+const (
+	slackEndpoint      = "https://slack.com/api"
+	slackMaxTextLength = 4000
+	// slackMaxAge is how old a signed request may be, against replays
+	slackMaxAge = 5 * time.Minute
+)
+
+// Slack receives messages from the Slack Events API and slash commands and
+// sends messages back through the Web API.
+type Slack struct {
+	signingSecret string
+	botToken      string
+	endpoint      string
+	client        *http.Client
+}
+
+// NewSlack makes a Slack adapter. signingSecret verifies requests from
+// Slack and botToken posts messages. endpoint defaults to the Web API when
+// empty.
+func NewSlack(signingSecret, botToken, endpoint string) *Slack {
+	if endpoint == "" {
+		endpoint = slackEndpoint
+	}
+	return &Slack{
+		signingSecret: signingSecret,
+		botToken:      botToken,
+		endpoint:      strings.TrimSuffix(endpoint, "/"),
+		client:        newClient("slack"),
+	}
+}
+
+type slackEnvelope struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	EventID   string `json:"event_id"`
+	Event     struct {
+		Type    string `json:"type"`
+		Subtype string `json:"subtype"`
+		BotID   string `json:"bot_id"`
+		User    string `json:"user"`
+		Channel string `json:"channel"`
+		Text    string `json:"text"`
+	} `json:"event"`
+}
+
+func (s *Slack) Name() string {
+	return PlatformSlack
+}
+
+// verify checks the signature Slack puts on every request and returns the
+// request body.
+func (s *Slack) verify(r *http.Request) ([]byte, error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	timestamp := r.Header.Get("X-Slack-Request-Timestamp")
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+	if age := time.Since(time.Unix(sec, 0)); age > slackMaxAge || age < -slackMaxAge {
+		return nil, ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(r.Header.Get("X-Slack-Signature")), []byte(SlackSignature(s.signingSecret, timestamp, body))) {
+		return nil, ErrInvalidSignature
+	}
+	return body, nil
+}
+
+// SlackSignature signs body the way Slack does.
+func SlackSignature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// ParseEvent reads a request from the Events API. It returns the challenge
+// to answer for URL verification, and a nil event for anything but messages
+// from people.
+func (s *Slack) ParseEvent(r *http.Request) (*Event, string, error) {
+	body, err := s.verify(r)
+	if err != nil {
+		return nil, "", err
+	}
+	var envelope slackEnvelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, "", err
+	}
+	if envelope.Type == "url_verification" {
+		return nil, envelope.Challenge, nil
+	}
+	// the bot's own messages come back as events too
+	e := envelope.Event
+	if envelope.Type != "event_callback" || e.Type != "message" || e.Subtype != "" || e.BotID != "" || e.Text == "" {
+		return nil, "", nil
+	}
+	return &Event{
+		Platform: s,
+		Source:   ID(PlatformSlack, e.Channel),
+		UserID:   ID(PlatformSlack, e.User),
+		Text:     e.Text,
+		EventID:  ID(PlatformSlack, envelope.EventID),
+	}, "", nil
+}
+
+// ParseCommand reads a slash command. Its text is the bot command, so
+// "/kentang add foo bar" runs "add foo bar".
+func (s *Slack) ParseCommand(r *http.Request) (*Event, error) {
+	body, err := s.verify(r)
+	if err != nil {
+		return nil, err
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, err
+	}
+	return &Event{
+		Platform:   s,
+		Source:     ID(PlatformSlack, form.Get("channel_id")),
+		UserID:     ID(PlatformSlack, form.Get("user_id")),
+		UserName:   form.Get("user_name"),
+		ReplyToken: form.Get("response_url"),
+		Text:       strings.TrimSpace(form.Get("text")),
+	}, nil
+}
+
+// Reply answers slash commands through their response URL and messages in
+// their channel.
+func (s *Slack) Reply(event *Event, messages ...string) error {
+	if event.ReplyToken == "" {
+		return s.Push(event.Context(), event.Source, messages...)
+	}
+	for _, message := range messages {
+		_, status, err := postJSON(event.Context(), s.client, event.ReplyToken, "", map[string]string{
+			"response_type": "in_channel",
+			"text":          render.Truncate(message, slackMaxTextLength),
+		})
+		if err != nil {
+			return fmt.Errorf("slack response: %v", err)
+		}
+		if status != http.StatusOK {
+			return fmt.Errorf("slack response: status %d", status)
+		}
+	}
+	return nil
+}
+
+func (s *Slack) Push(ctx context.Context, to string, messages ...string) error {
+	for _, message := range messages {
+		body, _, err := postJSON(ctx, s.client, s.endpoint+"/chat.postMessage", "Bearer "+s.botToken, map[string]string{
+			"channel": LocalID(to),
+			"text":    render.Truncate(message, slackMaxTextLength),
+		})
+		if err != nil {
+			return fmt.Errorf("slack chat.postMessage: %v", err)
+		}
+		var result struct {
+			OK    bool   `json:"ok"`
+			Error string `json:"error"`
+		}
+		if err := json.Unmarshal(body, &result); err != nil {
+			return fmt.Errorf("slack chat.postMessage: %v", err)
+		}
+		if !result.OK {
+			return fmt.Errorf("slack chat.postMessage: %s", result.Error)
+		}
+	}
+	return nil
+}
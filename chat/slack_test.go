@@ -0,0 +1,80 @@
+package chat
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func slackRequest(secret, body string, at time.Time) *http.Request {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	r := httptest.NewRequest("POST", "/slack", strings.NewReader(body))
+	r.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	r.Header.Set("X-Slack-Signature", SlackSignature(secret, timestamp, []byte(body)))
+	return r
+}
+
+func TestSlackParseEvent(t *testing.T) {
+	s := NewSlack("s3cret", "xoxb", "")
+	body := `{"type":"event_callback","event":{"type":"message","user":"U1","channel":"C1","text":"list"}}`
+
+	event, _, err := s.ParseEvent(slackRequest("s3cret", body, time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Source != "slack:C1" || event.UserID != "slack:U1" || event.Text != "list" {
+		t.Errorf("got %+v", event)
+	}
+
+	if _, _, err := s.ParseEvent(slackRequest("wrong", body, time.Now())); err != ErrInvalidSignature {
+		t.Errorf("wrong secret: got %v", err)
+	}
+	if _, _, err := s.ParseEvent(slackRequest("s3cret", body, time.Now().Add(-time.Hour))); err != ErrInvalidSignature {
+		t.Errorf("replayed: got %v", err)
+	}
+
+	bot := `{"type":"event_callback","event":{"type":"message","bot_id":"B1","channel":"C1","text":"list lagi?"}}`
+	if event, _, _ := s.ParseEvent(slackRequest("s3cret", bot, time.Now())); event != nil {
+		t.Errorf("bot message: got %+v", event)
+	}
+
+	challenge := `{"type":"url_verification","challenge":"abc"}`
+	if _, got, _ := s.ParseEvent(slackRequest("s3cret", challenge, time.Now())); got != "abc" {
+		t.Errorf("challenge: got %q", got)
+	}
+}
+
+func TestSlackCommand(t *testing.T) {
+	var got map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer server.Close()
+
+	s := NewSlack("s3cret", "xoxb", "")
+	form := url.Values{
+		"channel_id":   {"C1"},
+		"user_id":      {"U1"},
+		"user_name":    {"niki"},
+		"text":         {"add telat terlambat"},
+		"response_url": {server.URL},
+	}
+	event, err := s.ParseCommand(slackRequest("s3cret", form.Encode(), time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Text != "add telat terlambat" || event.UserName != "niki" {
+		t.Errorf("got %+v", event)
+	}
+	if err := event.Reply("telat has been added"); err != nil {
+		t.Fatal(err)
+	}
+	if got["text"] != "telat has been added" || got["response_type"] != "in_channel" {
+		t.Errorf("response: got %v", got)
+	}
+}
@@ -0,0 +1,153 @@
+package chat
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/luqmanarifin/kentang/render"
+)
+
+const (
+	telegramEndpoint      = "https://api.telegram.org"
+	telegramMaxTextLength = 4096
+	telegramSecretHeader  = "X-Telegram-Bot-Api-Secret-Token"
+)
+
+// Telegram receives updates from a Telegram Bot API webhook and sends
+// messages back through the Bot API.
+type Telegram struct {
+	token    string
+	secret   string
+	endpoint string
+	client   *http.Client
+}
+
+// NewTelegram makes a Telegram adapter for the bot with token. secret is the
+// secret_token given to setWebhook. endpoint defaults to the Bot API when
+// empty.
+func NewTelegram(token, secret, endpoint string) *Telegram {
+	if endpoint == "" {
+		endpoint = telegramEndpoint
+	}
+	return &Telegram{
+		token:    token,
+		secret:   secret,
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		client:   newClient("telegram"),
+	}
+}
+
+type telegramUpdate struct {
+	UpdateID int64            `json:"update_id"`
+	Message  *telegramMessage `json:"message"`
+}
+
+type telegramMessage struct {
+	MessageID int64         `json:"message_id"`
+	From      *telegramUser `json:"from"`
+	Chat      telegramChat  `json:"chat"`
+	Text      string        `json:"text"`
+}
+
+type telegramUser struct {
+	ID        int64  `json:"id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+type telegramChat struct {
+	ID int64 `json:"id"`
+}
+
+func (t *Telegram) Name() string {
+	return PlatformTelegram
+}
+
+// ParseRequest reads the text message of a webhook update. It returns a nil
+// event for updates that aren't text messages. Without a secret every update
+// is refused, as anyone could send one.
+func (t *Telegram) ParseRequest(r *http.Request) (*Event, error) {
+	got := r.Header.Get(telegramSecretHeader)
+	if t.secret == "" || subtle.ConstantTimeCompare([]byte(got), []byte(t.secret)) != 1 {
+		return nil, ErrInvalidSignature
+	}
+	var update telegramUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		return nil, err
+	}
+	m := update.Message
+	if m == nil || m.Text == "" {
+		return nil, nil
+	}
+
+	event := &Event{
+		Platform:   t,
+		Source:     ID(PlatformTelegram, strconv.FormatInt(m.Chat.ID, 10)),
+		ReplyToken: strconv.FormatInt(m.MessageID, 10),
+		Text:       telegramCommand(m.Text),
+		EventID:    ID(PlatformTelegram, strconv.FormatInt(update.UpdateID, 10)),
+	}
+	if m.From != nil {
+		event.UserID = ID(PlatformTelegram, strconv.FormatInt(m.From.ID, 10))
+		event.UserName = strings.TrimSpace(m.From.FirstName + " " + m.From.LastName)
+	}
+	return event, nil
+}
+
+// telegramCommand turns "/add@bot foo" into "add foo", since Telegram
+// clients suggest commands with a slash and the bot's name.
+func telegramCommand(text string) string {
+	if !strings.HasPrefix(text, "/") {
+		return text
+	}
+	command, rest := text[1:], ""
+	if i := strings.Index(command, " "); i >= 0 {
+		command, rest = command[:i], command[i:]
+	}
+	if i := strings.Index(command, "@"); i >= 0 {
+		command = command[:i]
+	}
+	return command + rest
+}
+
+func (t *Telegram) Reply(event *Event, messages ...string) error {
+	return t.Push(event.Context(), event.Source, messages...)
+}
+
+func (t *Telegram) Push(ctx context.Context, to string, messages ...string) error {
+	chatID := LocalID(to)
+	for _, message := range messages {
+		err := t.call(ctx, "sendMessage", map[string]string{
+			"chat_id": chatID,
+			"text":    render.Truncate(message, telegramMaxTextLength),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Telegram) call(ctx context.Context, method string, params interface{}) error {
+	body, _, err := postJSON(ctx, t.client, t.endpoint+"/bot"+t.token+"/"+method, "", params)
+	if err != nil {
+		return fmt.Errorf("telegram %s: %v", method, err)
+	}
+
+	var result struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("telegram %s: %v", method, err)
+	}
+	if !result.OK {
+		return fmt.Errorf("telegram %s: %s", method, result.Description)
+	}
+	return nil
+}
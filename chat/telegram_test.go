@@ -0,0 +1,112 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTelegramParseRequest(t *testing.T) {
+	tg := NewTelegram("token", "s3cret", "")
+	body := `{"update_id":1,"message":{"message_id":7,"from":{"id":42,"first_name":"Niki","last_name":"L"},"chat":{"id":-100},"text":"/add@kentang_bot telat terlambat"}}`
+
+	r := httptest.NewRequest("POST", "/telegram", strings.NewReader(body))
+	if _, err := tg.ParseRequest(r); err != ErrInvalidSignature {
+		t.Fatalf("unsigned request: got %v, want ErrInvalidSignature", err)
+	}
+
+	r = httptest.NewRequest("POST", "/telegram", strings.NewReader(body))
+	r.Header.Set(telegramSecretHeader, "s3cret")
+	event, err := tg.ParseRequest(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Source != "telegram:-100" || event.UserID != "telegram:42" || event.UserName != "Niki L" {
+		t.Errorf("got %+v", event)
+	}
+	if event.Text != "add telat terlambat" {
+		t.Errorf("text: got %q", event.Text)
+	}
+}
+
+func TestTelegramWithoutSecret(t *testing.T) {
+	tg := NewTelegram("token", "", "")
+	r := httptest.NewRequest("POST", "/telegram", strings.NewReader(`{"update_id":1,"message":{"message_id":7,"chat":{"id":-100},"text":"/reset confirm"}}`))
+	if _, err := tg.ParseRequest(r); err != ErrInvalidSignature {
+		t.Errorf("got %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestTelegramPush(t *testing.T) {
+	var got []map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bottoken/sendMessage" {
+			t.Errorf("path: got %s", r.URL.Path)
+		}
+		var params map[string]string
+		json.NewDecoder(r.Body).Decode(&params)
+		got = append(got, params)
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	tg := NewTelegram("token", "", server.URL)
+	event := &Event{Platform: tg, Source: "telegram:-100"}
+	if err := event.Reply("one", "two"); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0]["chat_id"] != "-100" || got[1]["text"] != "two" {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestTelegramError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(400)
+		w.Write([]byte(`{"ok":false,"description":"Bad Request: chat not found"}`))
+	}))
+	defer server.Close()
+
+	err := NewTelegram("token", "", server.URL).Push(context.Background(), "telegram:1", "hi")
+	if err == nil || !strings.Contains(err.Error(), "chat not found") {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestTelegramReplyFollowsEventContext(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	tg := NewTelegram("token", "", server.URL)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	event := (&Event{Platform: tg, Source: "telegram:-100"}).WithContext(ctx)
+	if err := event.Reply("hi"); err == nil || !strings.Contains(err.Error(), "canceled") {
+		t.Errorf("got %v, want the event's context error", err)
+	}
+	if calls != 0 {
+		t.Errorf("sent %d requests after the event was cancelled", calls)
+	}
+}
+
+func TestPlatformOf(t *testing.T) {
+	cases := map[string]string{
+		"C1234":         PlatformLINE,
+		"telegram:-100": PlatformTelegram,
+	}
+	for id, want := range cases {
+		if got := PlatformOf(id); got != want {
+			t.Errorf("PlatformOf(%q): got %s, want %s", id, got, want)
+		}
+	}
+	if got := LocalID(ID(PlatformTelegram, "-100")); got != "-100" {
+		t.Errorf("LocalID: got %s", got)
+	}
+}
@@ -0,0 +1,287 @@
+// Package config loads the settings of the bot. Each setting comes from, in
+// increasing priority, its default, the YAML file given by -config or
+// KENTANG_CONFIG, the .env file, the environment and the command line.
+//
+// The environment variable of a setting is its env tag, its YAML key and flag
+// follow the yaml tags, e.g. MYSQL_HOST, mysql.host and -mysql.host.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/luqmanarifin/kentang/redact"
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	Env     string `yaml:"env" env:"APP_ENV" default:"local" usage:"production skips the .env file"`
+	Port    string `yaml:"port" env:"PORT" default:"8080"`
+	BaseURL string `yaml:"base_url" env:"BASE_URL" usage:"public https address of this app, used to serve chart images"`
+	// SigningSecret signs chart URLs and postback data, it defaults to the
+	// LINE channel secret.
+	SigningSecret string `yaml:"signing_secret" env:"SIGNING_SECRET" secret:"true"`
+
+	LINE     LINE     `yaml:"line"`
+	MySQL    MySQL    `yaml:"mysql"`
+	Redis    Redis    `yaml:"redis"`
+	Telegram Telegram `yaml:"telegram"`
+	Slack    Slack    `yaml:"slack"`
+	Discord  Discord  `yaml:"discord"`
+	HTTP     HTTP     `yaml:"http"`
+	Log      Log      `yaml:"log"`
+	Tracing  Tracing  `yaml:"tracing"`
+	Admin    Admin    `yaml:"admin"`
+}
+
+type LINE struct {
+	ChannelSecret string `yaml:"channel_secret" env:"CHANNEL_SECRET" required:"true" secret:"true"`
+	ChannelToken  string `yaml:"channel_token" env:"CHANNEL_TOKEN" required:"true" secret:"true"`
+}
+
+type MySQL struct {
+	User     string `yaml:"user" env:"MYSQL_USER" required:"true"`
+	Password string `yaml:"password" env:"MYSQL_PASSWORD" secret:"true"`
+	Host     string `yaml:"host" env:"MYSQL_HOST" required:"true"`
+	Port     string `yaml:"port" env:"MYSQL_PORT" default:"3306"`
+	Database string `yaml:"database" env:"MYSQL_DATABASE" required:"true"`
+	Charset  string `yaml:"charset" env:"MYSQL_CHARSET" default:"utf8mb4"`
+}
+
+type Redis struct {
+	URL string `yaml:"url" env:"REDIS_URL" required:"true" secret:"url" usage:"e.g. redis://:password@host:6379"`
+}
+
+// Telegram, Slack and Discord are optional, each is served when its token or
+// key is set.
+type Telegram struct {
+	Token  string `yaml:"token" env:"TELEGRAM_TOKEN" secret:"true"`
+	Secret string `yaml:"secret" env:"TELEGRAM_SECRET" secret:"true" usage:"the secret_token given to setWebhook, required with the token"`
+}
+
+type Slack struct {
+	SigningSecret string `yaml:"signing_secret" env:"SLACK_SIGNING_SECRET" secret:"true"`
+	BotToken      string `yaml:"bot_token" env:"SLACK_BOT_TOKEN" secret:"true"`
+}
+
+type Discord struct {
+	ApplicationID string `yaml:"application_id" env:"DISCORD_APPLICATION_ID"`
+	PublicKey     string `yaml:"public_key" env:"DISCORD_PUBLIC_KEY"`
+	BotToken      string `yaml:"bot_token" env:"DISCORD_BOT_TOKEN" secret:"true"`
+}
+
+type HTTP struct {
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"HTTP_READ_TIMEOUT" default:"10s"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"HTTP_READ_HEADER_TIMEOUT" default:"5s"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"HTTP_WRITE_TIMEOUT" default:"30s"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT" default:"2m"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" env:"HTTP_SHUTDOWN_TIMEOUT" default:"25s" usage:"how long SIGTERM waits for requests and queued events"`
+	TLSCertFile       string        `yaml:"tls_cert_file" env:"TLS_CERT_FILE" usage:"serves HTTPS directly instead of behind a proxy"`
+	TLSKeyFile        string        `yaml:"tls_key_file" env:"TLS_KEY_FILE"`
+}
+
+type Log struct {
+	Level       string `yaml:"level" env:"LOG_LEVEL" default:"info" usage:"debug, info, warn or error"`
+	Format      string `yaml:"format" env:"LOG_FORMAT" usage:"json or text, defaults to json in production"`
+	UserContent bool   `yaml:"user_content" env:"LOG_USER_CONTENT" usage:"log what users write, for debugging locally"`
+}
+
+type Tracing struct {
+	Endpoint    string `yaml:"endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT" usage:"OTLP/HTTP collector to send traces to, e.g. http://localhost:4318, tracing is off when empty"`
+	ServiceName string `yaml:"service_name" env:"OTEL_SERVICE_NAME" default:"kentang"`
+}
+
+type Admin struct {
+	Token string `yaml:"token" env:"ADMIN_TOKEN" secret:"true" usage:"bearer token of the admin API at /api/, which is off when empty"`
+}
+
+// setting is one leaf field of Config.
+type setting struct {
+	path  string
+	field reflect.StructField
+	value reflect.Value
+}
+
+func settings(c *Config) []setting {
+	var all []setting
+	var walk func(v reflect.Value, prefix string)
+	walk = func(v reflect.Value, prefix string) {
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			path := prefix + strings.Split(field.Tag.Get("yaml"), ",")[0]
+			if field.Type.Kind() == reflect.Struct {
+				walk(v.Field(i), path+".")
+				continue
+			}
+			all = append(all, setting{path: path, field: field, value: v.Field(i)})
+		}
+	}
+	walk(reflect.ValueOf(c).Elem(), "")
+	return all
+}
+
+func (s setting) set(value string) error {
+	switch s.value.Interface().(type) {
+	case string:
+		s.value.SetString(value)
+	case bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s: %q is not true or false", s.path, value)
+		}
+		s.value.SetBool(b)
+	case time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%s: %q is not a duration like 10s", s.path, value)
+		}
+		s.value.SetInt(int64(d))
+	default:
+		return fmt.Errorf("%s: unsupported type %s", s.path, s.field.Type)
+	}
+	return nil
+}
+
+// Load reads the configuration, with args being the command line arguments
+// without the program name.
+func Load(args []string) (Config, error) {
+	var c Config
+	all := settings(&c)
+	for _, s := range all {
+		if def := s.field.Tag.Get("default"); def != "" {
+			if err := s.set(def); err != nil {
+				return c, err
+			}
+		}
+	}
+
+	flags := flag.NewFlagSet("kentang", flag.ContinueOnError)
+	file := flags.String("config", os.Getenv("KENTANG_CONFIG"), "YAML file to read the settings from")
+	values := make(map[string]*string)
+	for _, s := range all {
+		usage := s.field.Tag.Get("usage")
+		if env := s.field.Tag.Get("env"); env != "" {
+			usage = strings.TrimSpace(usage + " (" + env + ")")
+		}
+		values[s.path] = flags.String(s.path, "", usage)
+	}
+	if err := flags.Parse(args); err != nil {
+		return c, err
+	}
+
+	if *file != "" {
+		b, err := ioutil.ReadFile(*file)
+		if err != nil {
+			return c, err
+		}
+		if err := yaml.Unmarshal(b, &c); err != nil {
+			return c, fmt.Errorf("%s: %v", *file, err)
+		}
+	}
+
+	env := c.Env
+	if e := os.Getenv("APP_ENV"); e != "" {
+		env = e
+	}
+	if env != "production" {
+		if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return c, fmt.Errorf(".env: %v", err)
+		}
+	}
+	for _, s := range all {
+		key := s.field.Tag.Get("env")
+		if value := os.Getenv(key); key != "" && value != "" {
+			if err := s.set(value); err != nil {
+				return c, err
+			}
+		}
+	}
+
+	var err error
+	flags.Visit(func(f *flag.Flag) {
+		for _, s := range all {
+			if s.path == f.Name && err == nil {
+				err = s.set(*values[s.path])
+			}
+		}
+	})
+	if err != nil {
+		return c, err
+	}
+
+	if c.SigningSecret == "" {
+		c.SigningSecret = c.LINE.ChannelSecret
+	}
+	c.BaseURL = strings.TrimSuffix(c.BaseURL, "/")
+	if c.Log.Format == "" {
+		c.Log.Format = "text"
+		if c.Env == "production" {
+			c.Log.Format = "json"
+		}
+	}
+	return c, c.Validate()
+}
+
+// Validate reports every missing or inconsistent setting at once.
+func (c *Config) Validate() error {
+	var problems []string
+	for _, s := range settings(c) {
+		if s.field.Tag.Get("required") == "true" && s.value.IsZero() {
+			problems = append(problems, "missing "+describe(s))
+		}
+	}
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		problems = append(problems, "log.format (LOG_FORMAT) must be json or text")
+	}
+	if (c.HTTP.TLSCertFile == "") != (c.HTTP.TLSKeyFile == "") {
+		problems = append(problems, "http.tls_cert_file and http.tls_key_file must be set together")
+	}
+	if c.Telegram.Token != "" && c.Telegram.Secret == "" {
+		problems = append(problems, "telegram.token is set but telegram.secret (TELEGRAM_SECRET) is missing")
+	}
+	if c.Slack.SigningSecret != "" && c.Slack.BotToken == "" {
+		problems = append(problems, "slack.signing_secret is set but slack.bot_token (SLACK_BOT_TOKEN) is missing")
+	}
+	if c.Discord.PublicKey != "" && c.Discord.ApplicationID == "" {
+		problems = append(problems, "discord.public_key is set but discord.application_id (DISCORD_APPLICATION_ID) is missing")
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid config:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+func describe(s setting) string {
+	if env := s.field.Tag.Get("env"); env != "" {
+		return s.path + " (" + env + ")"
+	}
+	return s.path
+}
+
+// String lists the settings for the logs. Secrets only show whether they are
+// set, and URLs keep their host but not their password.
+func (c Config) String() string {
+	var b strings.Builder
+	for _, s := range settings(&c) {
+		value := fmt.Sprint(s.value.Interface())
+		switch s.field.Tag.Get("secret") {
+		case "true":
+			value = redact.Secret(value)
+		case "url":
+			value = redact.URL(value)
+		}
+		if b.Len() > 0 {
+			b.WriteString(" ")
+		}
+		fmt.Fprintf(&b, "%s=%q", s.path, value)
+	}
+	return b.String()
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadPriority(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	ioutil.WriteFile(file, []byte(`
+env: production
+line:
+  channel_secret: file-secret
+  channel_token: file-token
+mysql:
+  user: kentang
+  host: file-host
+  database: kentang
+redis:
+  url: redis://localhost:6379
+http:
+  write_timeout: 1m
+`), 0600)
+	t.Setenv("MYSQL_HOST", "env-host")
+	t.Setenv("CHANNEL_TOKEN", "env-token")
+
+	c, err := Load([]string{"-config", file, "-mysql.host", "flag-host", "-http.idle_timeout", "5s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checks := []struct {
+		name      string
+		got, want interface{}
+	}{
+		{"from file", c.LINE.ChannelSecret, "file-secret"},
+		{"env over file", c.LINE.ChannelToken, "env-token"},
+		{"flag over env", c.MySQL.Host, "flag-host"},
+		{"default", c.MySQL.Port, "3306"},
+		{"duration from file", c.HTTP.WriteTimeout, time.Minute},
+		{"duration from flag", c.HTTP.IdleTimeout, 5 * time.Second},
+		{"signing secret defaults to the channel secret", c.SigningSecret, "file-secret"},
+	}
+	for _, check := range checks {
+		if check.got != check.want {
+			t.Errorf("%s: got %v, want %v", check.name, check.got, check.want)
+		}
+	}
+}
+
+func TestLoadReportsEveryProblem(t *testing.T) {
+	for _, key := range []string{"CHANNEL_SECRET", "CHANNEL_TOKEN", "MYSQL_USER", "MYSQL_HOST", "MYSQL_DATABASE", "REDIS_URL"} {
+		t.Setenv(key, "")
+	}
+	t.Setenv("APP_ENV", "production")
+	_, err := Load([]string{"-http.tls_cert_file", "cert.pem", "-telegram.token", "token"})
+	if err == nil {
+		t.Fatal("loaded without the required settings")
+	}
+	for _, want := range []string{"line.channel_secret (CHANNEL_SECRET)", "redis.url (REDIS_URL)", "tls_key_file", "telegram.secret (TELEGRAM_SECRET)"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("got %q, want it to mention %s", err.Error(), want)
+		}
+	}
+}
+
+func TestLoadInvalidDuration(t *testing.T) {
+	t.Setenv("APP_ENV", "production")
+	t.Setenv("HTTP_READ_TIMEOUT", "ten")
+	if _, err := Load(nil); err == nil || !strings.Contains(err.Error(), "http.read_timeout") {
+		t.Errorf("got %v, want an error about http.read_timeout", err)
+	}
+}
+
+func TestMain(m *testing.M) {
+	// keep the settings of whoever runs the tests out
+	for _, s := range settings(&Config{}) {
+		os.Unsetenv(s.field.Tag.Get("env"))
+	}
+	os.Exit(m.Run())
+}
+
+func TestStringHidesSecrets(t *testing.T) {
+	var c Config
+	c.LINE.ChannelSecret = "line-secret"
+	c.MySQL.Host = "db.internal"
+	c.MySQL.Password = "db-password"
+	c.Redis.URL = "redis://:redis-password@cache.internal:6379"
+	c.Admin.Token = "admin-token"
+
+	s := c.String()
+	for _, secret := range []string{"line-secret", "db-password", "redis-password", "admin-token"} {
+		if strings.Contains(s, secret) {
+			t.Errorf("%q leaks %s", s, secret)
+		}
+	}
+	for _, target := range []string{"db.internal", "cache.internal:6379"} {
+		if !strings.Contains(s, target) {
+			t.Errorf("%q hides %s", s, target)
+		}
+	}
+}
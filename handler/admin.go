@@ -0,0 +1,447 @@
+package handler
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/luqmanarifin/kentang/logging"
+	"github.com/luqmanarifin/kentang/model"
+	"github.com/luqmanarifin/kentang/service"
+	"github.com/luqmanarifin/kentang/util"
+)
+
+const (
+	// entriesPageSize is the page size of entries when none is asked for,
+	// and maxEntriesPageSize the largest one allowed
+	entriesPageSize    = 100
+	maxEntriesPageSize = 1000
+
+	// maxAPIBody bounds the JSON bodies the admin API reads.
+	maxAPIBody = 64 << 10
+)
+
+type apiError struct {
+	Error string `json:"error"`
+}
+
+type entriesPage struct {
+	Entries []model.Entry `json:"entries"`
+	// Next is the after parameter of the next page, zero on the last one.
+	Next int `json:"next,omitempty"`
+}
+
+// personStanding is a keyword's position among the ones counted against a
+// user.
+type personStanding struct {
+	model.Standing
+	Target string `json:"target"`
+}
+
+type leaderboard struct {
+	From      time.Time        `json:"from"`
+	To        time.Time        `json:"to"`
+	Standings []model.Standing `json:"standings"`
+	People    []personStanding `json:"people"`
+}
+
+// Admin - the admin API, JSON over the dictionaries and entries of every
+// source. It answers only requests bearing the admin token, and is off when
+// there is none.
+//
+//	GET    /api/sources
+//	GET    /api/sources/{source}/dictionaries
+//	POST   /api/sources/{source}/dictionaries
+//	GET    /api/sources/{source}/dictionaries/{id}
+//	PUT    /api/sources/{source}/dictionaries/{id}
+//	DELETE /api/sources/{source}/dictionaries/{id}
+//	GET    /api/sources/{source}/entries?keyword=&target=&from=&to=&after=&limit=
+//	GET    /api/sources/{source}/leaderboard?from=&to=
+//
+// Times are RFC 3339. Entries come by ID, a page at a time: the next one is
+// asked for with after set to the next of the previous page.
+func (h *Handler) Admin(w http.ResponseWriter, r *http.Request) {
+	if h.adminToken == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if !h.adminAuthorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="kentang"`)
+		writeAPIError(w, http.StatusUnauthorized, "invalid or missing bearer token")
+		return
+	}
+
+	routes := h.adminRoutes(strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api"), "/"), "/"))
+	if routes == nil {
+		writeAPIError(w, http.StatusNotFound, "not found")
+		return
+	}
+	serve, ok := routes[r.Method]
+	if !ok {
+		var allowed []string
+		for method := range routes {
+			allowed = append(allowed, method)
+		}
+		sort.Strings(allowed)
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		writeAPIError(w, http.StatusMethodNotAllowed, r.Method+" is not allowed")
+		return
+	}
+	serve(w, r)
+}
+
+func (h *Handler) adminAuthorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(h.adminToken)) == 1
+}
+
+// adminRoutes returns what serves the path split into parts, by method, or
+// nil when nothing does.
+func (h *Handler) adminRoutes(parts []string) map[string]http.HandlerFunc {
+	if len(parts) == 1 && parts[0] == "sources" {
+		return map[string]http.HandlerFunc{http.MethodGet: h.apiSources}
+	}
+	if len(parts) < 3 || parts[0] != "sources" || parts[1] == "" {
+		return nil
+	}
+	source := parts[1]
+	switch {
+	case len(parts) == 3 && parts[2] == "dictionaries":
+		return map[string]http.HandlerFunc{
+			http.MethodGet: func(w http.ResponseWriter, r *http.Request) {
+				h.apiDictionaries(w, r, source)
+			},
+			http.MethodPost: func(w http.ResponseWriter, r *http.Request) {
+				h.apiCreateDictionary(w, r, source)
+			},
+		}
+	case len(parts) == 4 && parts[2] == "dictionaries":
+		id, err := strconv.Atoi(parts[3])
+		if err != nil {
+			return nil
+		}
+		return map[string]http.HandlerFunc{
+			http.MethodGet: func(w http.ResponseWriter, r *http.Request) {
+				if dict, ok := h.apiDictionary(w, r, source, id); ok {
+					writeJSON(w, http.StatusOK, dict)
+				}
+			},
+			http.MethodPut: func(w http.ResponseWriter, r *http.Request) {
+				h.apiUpdateDictionary(w, r, source, id)
+			},
+			http.MethodDelete: func(w http.ResponseWriter, r *http.Request) {
+				h.apiRemoveDictionary(w, r, source, id)
+			},
+		}
+	case len(parts) == 3 && parts[2] == "entries":
+		return map[string]http.HandlerFunc{
+			http.MethodGet: func(w http.ResponseWriter, r *http.Request) {
+				h.apiEntries(w, r, source)
+			},
+		}
+	case len(parts) == 3 && parts[2] == "leaderboard":
+		return map[string]http.HandlerFunc{
+			http.MethodGet: func(w http.ResponseWriter, r *http.Request) {
+				h.apiLeaderboard(w, r, source)
+			},
+		}
+	}
+	return nil
+}
+
+func (h *Handler) apiSources(w http.ResponseWriter, r *http.Request) {
+	sources, err := h.store.GetSources(r.Context())
+	if err != nil {
+		internalError(w, r, "Cannot fetch sources", err)
+		return
+	}
+	if sources == nil {
+		sources = []string{}
+	}
+	writeJSON(w, http.StatusOK, map[string][]string{"sources": sources})
+}
+
+func (h *Handler) apiDictionaries(w http.ResponseWriter, r *http.Request, source string) {
+	dicts, err := h.store.GetAllDictionaries(r.Context(), source)
+	if err != nil {
+		internalError(w, r, "Cannot fetch keywords", err)
+		return
+	}
+	if dicts == nil {
+		dicts = []model.Dictionary{}
+	}
+	writeJSON(w, http.StatusOK, map[string][]model.Dictionary{"dictionaries": dicts})
+}
+
+// apiDictionary fetches the dictionary of source with id, answering 404 when
+// there is none.
+func (h *Handler) apiDictionary(w http.ResponseWriter, r *http.Request, source string, id int) (model.Dictionary, bool) {
+	dict, err := h.store.GetDictionary(r.Context(), id)
+	if err == sql.ErrNoRows || err == nil && dict.Source != source {
+		writeAPIError(w, http.StatusNotFound, "no keyword "+strconv.Itoa(id)+" in "+source)
+		return model.Dictionary{}, false
+	}
+	if err != nil {
+		internalError(w, r, "Cannot fetch keyword", err)
+		return model.Dictionary{}, false
+	}
+	return dict, true
+}
+
+// apiCreateDictionary adds a keyword, attached to the user given as target if
+// any, like add and @user add do.
+func (h *Handler) apiCreateDictionary(w http.ResponseWriter, r *http.Request, source string) {
+	var dict model.Dictionary
+	if !readJSON(w, r, &dict) {
+		return
+	}
+	if len(strings.Fields(dict.Keyword)) != 1 || dict.Keyword != strings.TrimSpace(dict.Keyword) {
+		writeAPIError(w, http.StatusBadRequest, "keyword must be one word")
+		return
+	}
+	if strings.TrimSpace(dict.Description) == "" {
+		writeAPIError(w, http.StatusBadRequest, "description is required")
+		return
+	}
+
+	ctx := r.Context()
+	existing, err := h.store.GetDictionaryByTarget(ctx, source, dict.Keyword, dict.Target)
+	if err == nil && existing.Keyword == dict.Keyword {
+		writeAPIError(w, http.StatusConflict, dict.Keyword+" is already here before.")
+		return
+	}
+	if err != nil && err != sql.ErrNoRows {
+		internalError(w, r, "Cannot fetch keyword", err)
+		return
+	}
+	dict.ID = 0
+	dict.Source = source
+	if err := h.store.CreateDictionary(ctx, &dict); err != nil {
+		internalError(w, r, "Cannot add keyword", err)
+		return
+	}
+	// MySQL doesn't fill in the ID
+	created, err := h.store.GetDictionaryByTarget(ctx, source, dict.Keyword, dict.Target)
+	if err != nil {
+		internalError(w, r, "Cannot fetch added keyword", err)
+		return
+	}
+	h.cacheKeyword(ctx, created)
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// apiUpdateDictionary changes the description of a keyword, the only part of
+// it that doesn't identify its entries.
+func (h *Handler) apiUpdateDictionary(w http.ResponseWriter, r *http.Request, source string, id int) {
+	var body struct {
+		Description string `json:"description"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	if strings.TrimSpace(body.Description) == "" {
+		writeAPIError(w, http.StatusBadRequest, "description is required")
+		return
+	}
+	dict, ok := h.apiDictionary(w, r, source, id)
+	if !ok {
+		return
+	}
+	dict.Description = body.Description
+	if err := h.store.UpdateDictionary(r.Context(), &dict); err != nil {
+		internalError(w, r, "Cannot update keyword", err)
+		return
+	}
+	h.cacheKeyword(r.Context(), dict)
+	writeJSON(w, http.StatusOK, dict)
+}
+
+// apiRemoveDictionary removes a keyword with everything counted for it, like
+// remove does.
+func (h *Handler) apiRemoveDictionary(w http.ResponseWriter, r *http.Request, source string, id int) {
+	dict, ok := h.apiDictionary(w, r, source, id)
+	if !ok {
+		return
+	}
+	if err := h.removeKeyword(r.Context(), dict); err != nil {
+		internalError(w, r, "Cannot remove keyword", err)
+		return
+	}
+	if dict.Target == "" {
+		if err := h.cache.RemoveKeyword(r.Context(), source, dict.Keyword); err != nil {
+			slog.ErrorContext(r.Context(), "Cannot uncache keyword", "keyword", dict.Keyword, logging.Err(err))
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// cacheKeyword keeps the cached description of a plain keyword up to date.
+// Person keywords aren't cached.
+func (h *Handler) cacheKeyword(ctx context.Context, dict model.Dictionary) {
+	if dict.Target != "" {
+		return
+	}
+	if err := h.cache.AddKeyword(ctx, dict.Source, dict.Keyword, dict.Description); err != nil {
+		slog.ErrorContext(ctx, "Cannot cache keyword", "keyword", dict.Keyword, logging.Err(err))
+	}
+}
+
+func (h *Handler) apiEntries(w http.ResponseWriter, r *http.Request, source string) {
+	query := r.URL.Query()
+	filter := service.EntryFilter{
+		Source:  source,
+		Keyword: query.Get("keyword"),
+		Target:  query.Get("target"),
+		Limit:   entriesPageSize,
+	}
+	var err error
+	if filter.From, filter.To, err = queryPeriod(r); err != nil {
+		writeAPIError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if after := query.Get("after"); after != "" {
+		if filter.AfterID, err = strconv.Atoi(after); err != nil || filter.AfterID < 0 {
+			writeAPIError(w, http.StatusBadRequest, "after must be an entry ID")
+			return
+		}
+	}
+	if limit := query.Get("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil || filter.Limit < 1 || filter.Limit > maxEntriesPageSize {
+			writeAPIError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxEntriesPageSize))
+			return
+		}
+	}
+
+	// one more tells whether there is a next page
+	limit := filter.Limit
+	filter.Limit++
+	entries, err := h.store.GetEntries(r.Context(), filter)
+	if err != nil {
+		internalError(w, r, "Cannot fetch entries", err)
+		return
+	}
+	page := entriesPage{Entries: entries}
+	if len(entries) > limit {
+		page.Entries = entries[:limit]
+		page.Next = entries[limit-1].ID
+	}
+	if page.Entries == nil {
+		page.Entries = []model.Entry{}
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// apiLeaderboard ranks the keywords counted between from and to, all of them
+// when neither is given, as highscore does.
+func (h *Handler) apiLeaderboard(w http.ResponseWriter, r *http.Request, source string) {
+	from, to, err := queryPeriod(r)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if to.IsZero() {
+		to = time.Now()
+	}
+	entries, err := h.store.GetEntriesBetween(r.Context(), source, from, to)
+	if err != nil {
+		internalError(w, r, "Cannot fetch leaderboard entries", err)
+		return
+	}
+
+	board := leaderboard{
+		From:      from,
+		To:        to,
+		Standings: h.standings(r.Context(), source, entries),
+		People:    h.personStandings(r.Context(), source, entries),
+	}
+	if board.Standings == nil {
+		board.Standings = []model.Standing{}
+	}
+	if board.People == nil {
+		board.People = []personStanding{}
+	}
+	writeJSON(w, http.StatusOK, board)
+}
+
+// personStandings ranks the keywords of entries counted against users.
+func (h *Handler) personStandings(ctx context.Context, source string, entries []model.Entry) []personStanding {
+	m := make(map[string]int)
+	for _, entry := range entries {
+		if entry.Target != "" {
+			m[entry.Target+" "+entry.Keyword]++
+		}
+	}
+	pairs := util.MapToSortedPairs(m)
+	positions := util.Positions(pairs)
+	var standings []personStanding
+	for i, pair := range pairs {
+		parts := strings.SplitN(pair.Value, " ", 2)
+		target, keyword := parts[0], parts[1]
+		dict, _ := h.store.GetDictionaryByTarget(ctx, source, keyword, target)
+		standings = append(standings, personStanding{
+			Standing: model.Standing{
+				Source:      source,
+				Position:    positions[i],
+				Keyword:     keyword,
+				Description: dict.Description,
+				Count:       pair.Key,
+			},
+			Target: target,
+		})
+	}
+	return standings
+}
+
+// queryPeriod reads the from and to parameters, either may be left out.
+func queryPeriod(r *http.Request) (from, to time.Time, err error) {
+	for _, p := range []struct {
+		name string
+		t    *time.Time
+	}{{"from", &from}, {"to", &to}} {
+		value := r.URL.Query().Get(p.name)
+		if value == "" {
+			continue
+		}
+		if *p.t, err = time.Parse(time.RFC3339, value); err != nil {
+			return time.Time{}, time.Time{}, errors.New(p.name + " must be an RFC 3339 time, e.g. 2026-09-01T00:00:00+07:00")
+		}
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return time.Time{}, time.Time{}, errors.New("from must be before to")
+	}
+	return from, to, nil
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAPIBody)).Decode(v); err != nil {
+		writeAPIError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, apiError{Error: message})
+}
+
+// internalError logs err and answers 500 without it, it may tell more than
+// an API client should know.
+func internalError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	slog.ErrorContext(r.Context(), msg, "path", r.URL.Path, logging.Err(err))
+	writeAPIError(w, http.StatusInternalServerError, "internal error")
+}
@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/luqmanarifin/kentang/model"
+	"github.com/luqmanarifin/kentang/service"
+)
+
+func adminHandler() *Handler {
+	h := New(service.NewMemory(), service.NewMemoryCache())
+	h.adminToken = "token"
+	return h
+}
+
+// api serves a request to the admin API with the admin token, decoding the
+// answer into v when given.
+func api(t *testing.T, h *Handler, method, path, body string, v interface{}) int {
+	t.Helper()
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	r := httptest.NewRequest(method, path, reader)
+	r.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+	h.Admin(w, r)
+	if v != nil {
+		if err := json.NewDecoder(w.Body).Decode(v); err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+	}
+	return w.Code
+}
+
+func TestAdminAuth(t *testing.T) {
+	tests := []struct {
+		token string
+		auth  string
+		code  int
+	}{
+		{"", "Bearer ", 404},
+		{"token", "", 401},
+		{"token", "Bearer wrong", 401},
+		{"token", "token", 401},
+		{"token", "Bearer token", 200},
+	}
+	for _, test := range tests {
+		h := New(service.NewMemory(), service.NewMemoryCache())
+		h.adminToken = test.token
+		r := httptest.NewRequest(http.MethodGet, "/api/sources", nil)
+		r.Header.Set("Authorization", test.auth)
+		w := httptest.NewRecorder()
+		h.Admin(w, r)
+		if w.Code != test.code {
+			t.Errorf("token %q, authorization %q: got %d, want %d", test.token, test.auth, w.Code, test.code)
+		}
+	}
+}
+
+func TestAdminDictionaries(t *testing.T) {
+	h := adminHandler()
+	ctx := context.Background()
+
+	var created model.Dictionary
+	if code := api(t, h, "POST", "/api/sources/G1/dictionaries", `{"keyword":"telat","description":"terlambat","creator":"U1"}`, &created); code != 201 {
+		t.Fatalf("create: got %d", code)
+	}
+	if created.ID == 0 || created.Source != "G1" || created.Creator != "U1" {
+		t.Errorf("created %+v", created)
+	}
+	if desc, _ := h.cache.GetKeyword(ctx, "G1", "telat"); desc != "terlambat" {
+		t.Errorf("cached %q after create", desc)
+	}
+	if code := api(t, h, "POST", "/api/sources/G1/dictionaries", `{"keyword":"telat","description":"again"}`, nil); code != 409 {
+		t.Errorf("duplicate: got %d, want 409", code)
+	}
+	if code := api(t, h, "POST", "/api/sources/G1/dictionaries", `{"keyword":"two words","description":"x"}`, nil); code != 400 {
+		t.Errorf("two words: got %d, want 400", code)
+	}
+
+	path := "/api/sources/G1/dictionaries/" + strconv.Itoa(created.ID)
+	var updated model.Dictionary
+	if code := api(t, h, "PUT", path, `{"description":"late"}`, &updated); code != 200 || updated.Description != "late" {
+		t.Errorf("update: got %d %+v", code, updated)
+	}
+	if desc, _ := h.cache.GetKeyword(ctx, "G1", "telat"); desc != "late" {
+		t.Errorf("cached %q after update", desc)
+	}
+	if code := api(t, h, "GET", "/api/sources/G2/dictionaries/"+strconv.Itoa(created.ID), "", nil); code != 404 {
+		t.Errorf("other source: got %d, want 404", code)
+	}
+
+	var list struct{ Dictionaries []model.Dictionary }
+	api(t, h, "GET", "/api/sources/G1/dictionaries", "", &list)
+	if len(list.Dictionaries) != 1 || list.Dictionaries[0].Description != "late" {
+		t.Errorf("listed %+v", list.Dictionaries)
+	}
+
+	h.store.CreateEntry(ctx, &model.Entry{Source: "G1", Keyword: "telat"})
+	if code := api(t, h, "DELETE", path, "", nil); code != 204 {
+		t.Fatalf("delete: got %d", code)
+	}
+	if entries, _ := h.store.GetAllEntries(ctx, "G1"); len(entries) != 0 {
+		t.Errorf("%d entries left after delete", len(entries))
+	}
+	if desc, _ := h.cache.GetKeyword(ctx, "G1", "telat"); desc == "late" {
+		t.Error("still cached after delete")
+	}
+	if code := api(t, h, "PATCH", path, "", nil); code != 405 {
+		t.Errorf("patch: got %d, want 405", code)
+	}
+}
+
+func TestAdminEntries(t *testing.T) {
+	h := adminHandler()
+	ctx := context.Background()
+	for _, keyword := range []string{"telat", "bolos", "telat", "telat", "telat"} {
+		h.store.CreateEntry(ctx, &model.Entry{Source: "G1", Keyword: keyword})
+	}
+	h.store.CreateEntry(ctx, &model.Entry{Source: "G2", Keyword: "telat"})
+
+	var ids []int
+	path := "/api/sources/G1/entries?keyword=telat&limit=3"
+	for page := 0; ; page++ {
+		var got entriesPage
+		if code := api(t, h, "GET", path, "", &got); code != 200 {
+			t.Fatalf("page %d: got %d", page, code)
+		}
+		for _, e := range got.Entries {
+			if e.Source != "G1" || e.Keyword != "telat" {
+				t.Errorf("page %d: got %+v", page, e)
+			}
+			ids = append(ids, e.ID)
+		}
+		if got.Next == 0 {
+			break
+		}
+		path = "/api/sources/G1/entries?keyword=telat&limit=3&after=" + strconv.Itoa(got.Next)
+	}
+	if len(ids) != 4 {
+		t.Errorf("got entries %v, want 4", ids)
+	}
+
+	for _, query := range []string{"limit=0", "limit=x", "after=-1", "from=yesterday", "from=2026-09-02T00:00:00Z&to=2026-09-01T00:00:00Z"} {
+		if code := api(t, h, "GET", "/api/sources/G1/entries?"+query, "", nil); code != 400 {
+			t.Errorf("%s: got %d, want 400", query, code)
+		}
+	}
+}
+
+func TestAdminLeaderboard(t *testing.T) {
+	h := adminHandler()
+	ctx := context.Background()
+	h.store.CreateDictionary(ctx, &model.Dictionary{Source: "G1", Keyword: "telat", Description: "terlambat"})
+	h.store.CreateDictionary(ctx, &model.Dictionary{Source: "G1", Keyword: "bolos", Description: "absen"})
+	h.store.CreateDictionary(ctx, &model.Dictionary{Source: "G1", Keyword: "gg", Description: "keren", Target: "U2"})
+	for _, e := range []model.Entry{{Keyword: "telat"}, {Keyword: "bolos"}, {Keyword: "telat"}, {Keyword: "gg", Target: "U2"}} {
+		e.Source = "G1"
+		h.store.CreateEntry(ctx, &e)
+	}
+
+	var got leaderboard
+	if code := api(t, h, "GET", "/api/sources/G1/leaderboard", "", &got); code != 200 {
+		t.Fatalf("got %d", code)
+	}
+	if len(got.Standings) != 2 || got.Standings[0].Keyword != "telat" || got.Standings[0].Count != 2 || got.Standings[0].Description != "terlambat" {
+		t.Errorf("got standings %+v", got.Standings)
+	}
+	if len(got.People) != 1 || got.People[0].Target != "U2" || got.People[0].Description != "keren" {
+		t.Errorf("got people %+v", got.People)
+	}
+
+	var sources struct{ Sources []string }
+	api(t, h, "GET", "/api/sources", "", &sources)
+	if strings.Join(sources.Sources, ",") != "G1" {
+		t.Errorf("got sources %v", sources.Sources)
+	}
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/luqmanarifin/kentang/achievement"
+	"github.com/luqmanarifin/kentang/chat"
+	"github.com/luqmanarifin/kentang/logging"
+	"github.com/luqmanarifin/kentang/model"
+	"github.com/luqmanarifin/kentang/scheduler"
+)
+
+// unlockBadges saves the badges a freshly counted entry has earned and returns
+// the announcement for them, if any. Badges earned by earlier counts, e.g.
+// before badges existed, are saved without being announced.
+func (h *Handler) unlockBadges(ctx context.Context, entry *model.Entry, desc string) []string {
+	loc := h.location(ctx, entry.Source)
+
+	count, err := h.store.CountEntriesByKeyword(ctx, entry.Source, entry.Keyword)
+	if err != nil {
+		slog.ErrorContext(ctx, "Cannot count entries", "keyword", entry.Keyword, logging.Err(err))
+		return nil
+	}
+	recent, err := h.store.GetEntriesByKeywordSince(ctx, entry.Source, entry.Keyword, achievement.StreakFrom(entry.Timestamp, loc))
+	if err != nil {
+		slog.ErrorContext(ctx, "Cannot fetch entries", "keyword", entry.Keyword, logging.Err(err))
+		return nil
+	}
+	dayStart := scheduler.Daily.Start(entry.Timestamp.In(loc))
+	today, err := h.store.GetEntriesBetween(ctx, entry.Source, dayStart, dayStart.AddDate(0, 0, 1))
+	if err != nil {
+		slog.ErrorContext(ctx, "Cannot fetch today's entries", logging.Err(err))
+		return nil
+	}
+	progress := achievement.Compute(count, recent, len(today) == 1, entry.Timestamp, loc)
+
+	badges, err := h.store.GetBadges(ctx, entry.Source, entry.Keyword)
+	if err != nil {
+		slog.ErrorContext(ctx, "Cannot fetch badges", "keyword", entry.Keyword, logging.Err(err))
+		return nil
+	}
+	owned := make(map[string]bool)
+	for _, b := range badges {
+		owned[b.Name] = true
+	}
+
+	var lines []string
+	for _, badge := range progress.Unlocked() {
+		if owned[badge.Name] {
+			continue
+		}
+		err := h.store.CreateBadge(ctx, &model.Badge{
+			Source:  entry.Source,
+			Keyword: entry.Keyword,
+			Name:    badge.Name,
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Cannot save badge", "badge", badge.Name, "keyword", entry.Keyword, logging.Err(err))
+			continue
+		}
+		if progress.Reached(badge) {
+			lines = append(lines, badgeAnnouncement(entry.Keyword, desc, badge))
+		}
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return []string{strings.Join(lines, "\n")}
+}
+
+func badgeAnnouncement(keyword, desc string, badge achievement.Badge) string {
+	for _, n := range achievement.Streaks {
+		if badge == achievement.Streak(n) {
+			return keyword + " has been " + desc + " " + strconv.Itoa(n) + " days in a row!"
+		}
+	}
+	for _, n := range achievement.Milestones {
+		if badge == achievement.Milestone(n) {
+			return keyword + " has been " + desc + " " + strconv.Itoa(n) + " times!"
+		}
+	}
+	return keyword + " unlocked " + badge.Title + ": " + badge.Description
+}
+
+func (h *Handler) handleBadges(event *chat.Event, tokens []string) {
+	if len(tokens) != 2 {
+		return
+	}
+	keyword := tokens[1]
+	source := event.Source
+
+	dict, err := h.store.GetDictionaryByKeyword(event.Context(), source, keyword)
+	if err != nil || dict.Keyword != keyword {
+		h.reply(event, "Keyword "+keyword+" is not exists")
+		return
+	}
+	loc := h.location(event.Context(), source)
+	now := time.Now()
+	count, err := h.store.CountEntriesByKeyword(event.Context(), source, keyword)
+	if err != nil {
+		slog.ErrorContext(event.Context(), "Cannot count entries", "keyword", keyword, logging.Err(err))
+		return
+	}
+	recent, err := h.store.GetEntriesByKeywordSince(event.Context(), source, keyword, achievement.StreakFrom(now, loc))
+	if err != nil {
+		slog.ErrorContext(event.Context(), "Cannot fetch entries", "keyword", keyword, logging.Err(err))
+		return
+	}
+	badges, err := h.store.GetBadges(event.Context(), source, keyword)
+	if err != nil {
+		slog.ErrorContext(event.Context(), "Cannot fetch badges", "keyword", keyword, logging.Err(err))
+		return
+	}
+
+	message := keyword + ": " + strconv.Itoa(count) + " times, " + strconv.Itoa(achievement.DailyStreak(recent, now, loc)) + " day streak"
+	if len(badges) == 0 {
+		message = message + "\nNo badges yet."
+	}
+	for _, b := range badges {
+		badge, ok := achievement.Find(b.Name)
+		if !ok {
+			continue
+		}
+		message = message + "\n- " + badge.Title + ": " + badge.Description + " (" + b.Timestamp.In(loc).Format("2 Jan 2006") + ")"
+	}
+	h.reply(event, message)
+}
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/luqmanarifin/kentang/model"
+	"github.com/luqmanarifin/kentang/service"
+)
+
+func TestBadgesEarnedBeforeAreNotAnnounced(t *testing.T) {
+	store := service.NewMemory()
+	_, api, send := newLineHandler(t, store, service.NewMemoryCache())
+	ctx := context.Background()
+
+	store.CreateDictionary(ctx, &model.Dictionary{Source: "G1", Keyword: "telat", Description: "terlambat"})
+	for i := 0; i < 11; i++ {
+		store.CreateEntry(ctx, &model.Entry{Source: "G1", Keyword: "telat"})
+	}
+	if got := send(api.TextEvent("G1", "U1", "telat")); strings.Contains(got, "10 times") {
+		t.Errorf("announced a milestone reached before: %q", got)
+	}
+	badges, _ := store.GetBadges(ctx, "G1", "telat")
+	saved := false
+	for _, b := range badges {
+		saved = saved || b.Name == "milestone-10"
+	}
+	if !saved {
+		t.Errorf("milestone-10 wasn't saved: %+v", badges)
+	}
+}
@@ -0,0 +1,229 @@
+package handler
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/line/line-bot-sdk-go/v7/linebot"
+	"github.com/luqmanarifin/kentang/chat"
+	"github.com/luqmanarifin/kentang/logging"
+	"github.com/luqmanarifin/kentang/model"
+)
+
+const (
+	bindingSticker  = "sticker"
+	bindingImage    = "image"
+	bindingVideo    = "video"
+	bindingAudio    = "audio"
+	bindingFile     = "file"
+	bindingLocation = "location"
+
+	pendingTTL = 5 * time.Minute
+)
+
+// commands that can't be bound, since a stray image shouldn't change a group
+var unbindableCommands = map[string]bool{
+	"add":            true,
+	"remove":         true,
+	"reset":          true,
+	"bind":           true,
+	"unbind":         true,
+	"add-sticker":    true,
+	"remove-sticker": true,
+}
+
+// commands that show a setting when given no argument and change it
+// otherwise, so only the former can be bound
+var settingCommands = map[string]bool{
+	"season":   true,
+	"timezone": true,
+	"recap":    true,
+	"style":    true,
+}
+
+// isBindableCommand tells whether running command on every message changes
+// nothing but the counts of a keyword.
+func isBindableCommand(command []string) bool {
+	name := strings.ToLower(command[0])
+	if settingCommands[name] {
+		return len(command) == 1
+	}
+	return !unbindableCommands[name]
+}
+
+// messageBinding returns the binding type and key of a non-text message.
+// Stickers are told apart by their IDs, other types bind as a whole.
+func messageBinding(message linebot.Message) (string, string, bool) {
+	switch m := message.(type) {
+	case *linebot.StickerMessage:
+		return bindingSticker, m.PackageID + ":" + m.StickerID, true
+	case *linebot.ImageMessage:
+		return bindingImage, "", true
+	case *linebot.VideoMessage:
+		return bindingVideo, "", true
+	case *linebot.AudioMessage:
+		return bindingAudio, "", true
+	case *linebot.FileMessage:
+		return bindingFile, "", true
+	case *linebot.LocationMessage:
+		return bindingLocation, "", true
+	}
+	return "", "", false
+}
+
+func isBindableType(typ string) bool {
+	switch typ {
+	case bindingImage, bindingVideo, bindingAudio, bindingFile, bindingLocation:
+		return true
+	}
+	return false
+}
+
+func (h *Handler) handleStickerMessage(event *chat.Event, message *linebot.StickerMessage) {
+	source := event.Source
+	keyword, err := h.cache.TakePending(event.Context(), source, event.UserID, bindingSticker)
+	if err != nil || keyword == "" {
+		h.handleBoundMessage(event, message)
+		return
+	}
+
+	err = h.store.SaveBinding(event.Context(), &model.Binding{
+		Source:  source,
+		Type:    bindingSticker,
+		Key:     message.PackageID + ":" + message.StickerID,
+		Command: keyword,
+		Creator: event.UserID,
+	})
+	if err != nil {
+		slog.ErrorContext(event.Context(), "Cannot bind sticker", "keyword", keyword, logging.Err(err))
+		return
+	}
+	committed(event.Context())
+	h.reply(event, "Sending this sticker now counts "+keyword)
+}
+
+// handleBoundMessage runs the command bound to a non-text message, if any.
+func (h *Handler) handleBoundMessage(event *chat.Event, message linebot.Message) {
+	typ, key, ok := messageBinding(message)
+	if !ok {
+		return
+	}
+	source := event.Source
+	binding, err := h.store.GetBinding(event.Context(), source, typ, key)
+	if err != nil {
+		return
+	}
+	slog.InfoContext(event.Context(), "Received bound message", "type", typ, "bound_to", binding.Command)
+	h.handleCommand(event, binding.Command)
+}
+
+func (h *Handler) handleAddSticker(event *chat.Event, tokens []string) {
+	if len(tokens) != 2 {
+		return
+	}
+	keyword := tokens[1]
+	source := event.Source
+	if !event.Is(chat.PlatformLINE) {
+		h.reply(event, "Stickers can only be bound on LINE")
+		return
+	}
+	if event.UserID == "" {
+		h.reply(event, "Bot can't tell who sends the sticker without user ID")
+		return
+	}
+
+	dict, err := h.store.GetDictionaryByKeyword(event.Context(), source, keyword)
+	if err != nil || dict.Keyword != keyword {
+		h.reply(event, "Keyword "+keyword+" is not exists")
+		return
+	}
+	err = h.cache.SetPending(event.Context(), source, event.UserID, bindingSticker, keyword, pendingTTL)
+	if err != nil {
+		slog.ErrorContext(event.Context(), "Cannot wait for sticker", "keyword", keyword, logging.Err(err))
+		return
+	}
+	h.reply(event, "Send the sticker for "+keyword+" now")
+}
+
+func (h *Handler) handleRemoveSticker(event *chat.Event, tokens []string) {
+	if len(tokens) != 2 {
+		return
+	}
+	keyword := tokens[1]
+	source := event.Source
+	if err := h.store.RemoveBindingByCommand(event.Context(), source, bindingSticker, keyword); err != nil {
+		slog.ErrorContext(event.Context(), "Cannot remove stickers", "keyword", keyword, logging.Err(err))
+		return
+	}
+	committed(event.Context())
+	h.reply(event, "Stickers no longer count "+keyword)
+}
+
+func (h *Handler) handleBind(event *chat.Event, tokens []string) {
+	if len(tokens) < 3 {
+		return
+	}
+	typ := strings.ToLower(tokens[1])
+	if !isBindableType(typ) {
+		h.reply(event, "Only image, video, audio, file and location can be bound. Use add-sticker for stickers.")
+		return
+	}
+	command := strings.Join(tokens[2:], " ")
+	if !isBindableCommand(tokens[2:]) {
+		h.reply(event, command+" can't be bound")
+		return
+	}
+
+	source := event.Source
+	err := h.store.SaveBinding(event.Context(), &model.Binding{
+		Source:  source,
+		Type:    typ,
+		Command: command,
+		Creator: event.UserID,
+	})
+	if err != nil {
+		slog.ErrorContext(event.Context(), "Cannot bind", "type", typ, logging.Err(err))
+		return
+	}
+	committed(event.Context())
+	h.reply(event, "Sending "+typ+" now runs \""+command+"\"")
+}
+
+func (h *Handler) handleUnbind(event *chat.Event, tokens []string) {
+	if len(tokens) != 2 {
+		return
+	}
+	typ := strings.ToLower(tokens[1])
+	if !isBindableType(typ) {
+		return
+	}
+	source := event.Source
+	if err := h.store.RemoveBinding(event.Context(), source, typ, ""); err != nil {
+		slog.ErrorContext(event.Context(), "Cannot unbind", "type", typ, logging.Err(err))
+		return
+	}
+	committed(event.Context())
+	h.reply(event, typ+" unbound")
+}
+
+func (h *Handler) handleBindings(event *chat.Event, tokens []string) {
+	if len(tokens) != 1 {
+		return
+	}
+	source := event.Source
+	bindings, err := h.store.GetAllBindings(event.Context(), source)
+	if err != nil {
+		slog.ErrorContext(event.Context(), "Cannot fetch bindings", logging.Err(err))
+		return
+	}
+	if len(bindings) == 0 {
+		h.reply(event, "Nothing is bound.")
+		return
+	}
+	message := "Bindings:"
+	for _, b := range bindings {
+		message = message + "\n" + b.Type + " -> " + b.Command
+	}
+	h.reply(event, message)
+}
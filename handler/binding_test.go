@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/luqmanarifin/kentang/service"
+)
+
+func TestBindings(t *testing.T) {
+	store := service.NewMemory()
+	_, api, send := newLineHandler(t, store, service.NewMemoryCache())
+	ctx := context.Background()
+
+	counted := func() int {
+		t.Helper()
+		entries, err := store.GetEntriesByKeyword(ctx, "G1", "telat")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(entries)
+	}
+
+	send(api.TextEvent("G1", "U1", "add telat terlambat"))
+	if got := send(api.TextEvent("G1", "U1", "add-sticker telat")); got != "Send the sticker for telat now" {
+		t.Errorf("add-sticker: got %q", got)
+	}
+	if got := send(api.StickerEvent("G1", "U2", "1", "2")); got != "" || counted() != 0 {
+		t.Errorf("another member's sticker was taken: got %q", got)
+	}
+	if got := send(api.StickerEvent("G1", "U1", "1", "1")); got != "Sending this sticker now counts telat" {
+		t.Errorf("pending sticker: got %q", got)
+	}
+	if counted() != 0 {
+		t.Error("binding the sticker counted it")
+	}
+	send(api.StickerEvent("G1", "U2", "1", "1"))
+	if counted() != 1 {
+		t.Errorf("bound sticker: got %d entries, want 1", counted())
+	}
+	send(api.StickerEvent("G1", "U2", "1", "2"))
+	if counted() != 1 {
+		t.Errorf("unbound sticker: got %d entries, want 1", counted())
+	}
+
+	for _, command := range []string{"season end", "reset", "add foo bar", "remove telat", "timezone UTC", "unbind image"} {
+		if got := send(api.TextEvent("G1", "U1", "bind image "+command)); got != command+" can't be bound" {
+			t.Errorf("bind image %s: got %q", command, got)
+		}
+	}
+	if got := send(api.TextEvent("G1", "U1", "bind video season")); got != `Sending video now runs "season"` {
+		t.Errorf("bind video season: got %q", got)
+	}
+	if got := send(api.TextEvent("G1", "U1", "bind image telat")); got != `Sending image now runs "telat"` {
+		t.Errorf("bind image telat: got %q", got)
+	}
+	send(api.ImageEvent("G1", "U2"))
+	if counted() != 2 {
+		t.Errorf("bound image: got %d entries, want 2", counted())
+	}
+}
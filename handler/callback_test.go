@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/luqmanarifin/kentang/linetest"
+	"github.com/luqmanarifin/kentang/metrics"
+	"github.com/luqmanarifin/kentang/model"
+	"github.com/luqmanarifin/kentang/service"
+	"github.com/luqmanarifin/kentang/tracing"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestCallback(t *testing.T) {
+	h, api, _ := newLineHandler(t, service.NewMemory(), service.NewMemoryCache())
+	api.AddProfile("U1", "Niki", "")
+
+	steps := []struct {
+		text string
+		want string
+	}{
+		{"add telat terlambat", "telat has been added"},
+		{"telat", "telat, terlambat lagi?"},
+		{"list", "1. telat: terlambat (Niki)"},
+		{"highscore", "telat - terlambat : 1"},
+	}
+	for _, step := range steps {
+		api.Reset()
+		w := httptest.NewRecorder()
+		h.Callback(w, api.Webhook(api.TextEvent("G1", "U1", step.text)))
+		if w.Code != 200 {
+			t.Fatalf("%q: got status %d", step.text, w.Code)
+		}
+		h.queue.Wait()
+		replies := api.Replies()
+		if len(replies) != 1 {
+			t.Fatalf("%q: got %d replies, want 1", step.text, len(replies))
+		}
+		if got := strings.Join(replies[0].Texts(), "\n"); !strings.Contains(got, step.want) {
+			t.Errorf("%q: got %q, want it to contain %q", step.text, got, step.want)
+		}
+	}
+}
+
+func TestCallbackInvalidSignature(t *testing.T) {
+	h, api, _ := newLineHandler(t, service.NewMemory(), service.NewMemoryCache())
+
+	r := api.Webhook(api.TextEvent("G1", "U1", "list"))
+	r.Header.Set("X-Line-Signature", linetest.Sign("wrong", []byte("{}")))
+	w := httptest.NewRecorder()
+	h.Callback(w, r)
+	if w.Code != 400 {
+		t.Errorf("got status %d, want 400", w.Code)
+	}
+	h.queue.Wait()
+	if len(api.Replies()) != 0 {
+		t.Errorf("replied to a forged webhook")
+	}
+}
+
+func TestCallbackRedelivery(t *testing.T) {
+	store := service.NewMemory()
+	h, api, _ := newLineHandler(t, store, service.NewMemoryCache())
+
+	h.Callback(httptest.NewRecorder(), api.Webhook(api.TextEvent("G1", "U1", "add telat terlambat")))
+	event := api.TextEvent("G1", "U1", "telat")
+	h.Callback(httptest.NewRecorder(), api.Webhook(event))
+	h.Callback(httptest.NewRecorder(), api.Webhook(linetest.Redelivery(event)))
+	h.queue.Wait()
+
+	if replies := api.Replies(); len(replies) != 2 {
+		t.Errorf("got %d replies, want 2", len(replies))
+	}
+	entries, _ := store.GetAllEntries(context.Background(), "G1")
+	if len(entries) != 1 {
+		t.Errorf("got %d entries, want 1", len(entries))
+	}
+
+	// the entry's unique event ID holds even when the cache forgot the event
+	err := store.CreateEntry(context.Background(), &model.Entry{Source: "G1", Keyword: "telat", EventID: entries[0].EventID})
+	if err != service.ErrDuplicate {
+		t.Errorf("got %v, want ErrDuplicate", err)
+	}
+}
+
+// flakyStore can't store entries while fail is set.
+type flakyStore struct {
+	*service.Memory
+	fail bool
+}
+
+func (s *flakyStore) CreateEntry(ctx context.Context, entry *model.Entry) error {
+	if s.fail {
+		return errors.New("connection refused")
+	}
+	return s.Memory.CreateEntry(ctx, entry)
+}
+
+func TestCallbackRedeliveryAfterFailure(t *testing.T) {
+	store := &flakyStore{Memory: service.NewMemory()}
+	_, api, send := newLineHandler(t, store, service.NewMemoryCache())
+
+	send(api.TextEvent("G1", "U1", "add telat terlambat"))
+	store.fail = true
+	event := api.TextEvent("G1", "U1", "telat")
+	if got := send(event); got != "" {
+		t.Errorf("replied %q without counting", got)
+	}
+	store.fail = false
+	if got := send(linetest.Redelivery(event)); !strings.HasPrefix(got, "telat, terlambat lagi?") {
+		t.Errorf("got %q after the redelivery", got)
+	}
+
+	if entries, _ := store.GetAllEntries(context.Background(), "G1"); len(entries) != 1 {
+		t.Errorf("got %d entries after the redelivery, want 1", len(entries))
+	}
+}
+
+// uncachedKeywords can't cache keywords.
+type uncachedKeywords struct {
+	*service.MemoryCache
+}
+
+func (c uncachedKeywords) AddKeyword(ctx context.Context, source, keyword, desc string) error {
+	return errors.New("connection refused")
+}
+
+func TestCallbackRedeliveryAfterReply(t *testing.T) {
+	_, api, send := newLineHandler(t, service.NewMemory(), uncachedKeywords{service.NewMemoryCache()})
+
+	// caching the keyword fails after the reply, which mustn't be sent again
+	event := api.TextEvent("G1", "U1", "add telat terlambat")
+	if got := send(event); got != "telat has been added" {
+		t.Fatalf("got %q", got)
+	}
+	if got := send(linetest.Redelivery(event)); got != "" {
+		t.Errorf("replied %q to the redelivery", got)
+	}
+}
+
+func TestCallbackMetrics(t *testing.T) {
+	_, api, send := newLineHandler(t, service.NewMemory(), service.NewMemoryCache())
+
+	count := func(c prometheus.Collector) float64 { return testutil.ToFloat64(c) }
+	events := metrics.WebhookEvents.WithLabelValues("line", "message")
+	adds := metrics.Commands.WithLabelValues("add", "ok")
+	entries := metrics.KeywordEntries.WithLabelValues("line")
+	before := []float64{count(events), count(adds), count(entries)}
+
+	for _, text := range []string{"add telat terlambat", "telat"} {
+		send(api.TextEvent("G1", "U1", text))
+	}
+	after := []float64{count(events), count(adds), count(entries)}
+	for i, name := range []string{"events", "add commands", "entries"} {
+		if after[i]-before[i] != []float64{2, 1, 1}[i] {
+			t.Errorf("%s went from %v to %v", name, before[i], after[i])
+		}
+	}
+}
+
+func TestCallbackTrace(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer otel.SetTracerProvider(otel.GetTracerProvider())
+	otel.SetTracerProvider(provider)
+	defer provider.Shutdown(context.Background())
+
+	h, api, _ := newLineHandler(t, service.NewMemory(), service.NewMemoryCache())
+
+	r := api.Webhook(api.TextEvent("G1", "U1", "list"))
+	tracing.Handler("/callback", http.HandlerFunc(h.Callback)).ServeHTTP(httptest.NewRecorder(), r)
+	h.queue.Wait()
+
+	spans := make(map[string]tracetest.SpanStub)
+	for _, span := range exporter.GetSpans() {
+		spans[span.Name] = span
+	}
+	request := spans["POST /callback"]
+	for _, name := range []string{"line verify_signature", "event line", "command list"} {
+		span, ok := spans[name]
+		if !ok {
+			t.Errorf("no %q span in %v", name, spans)
+			continue
+		}
+		if span.SpanContext.TraceID() != request.SpanContext.TraceID() {
+			t.Errorf("%q isn't in the trace of the webhook", name)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/luqmanarifin/kentang/achievement"
+	"github.com/luqmanarifin/kentang/card"
+	"github.com/luqmanarifin/kentang/chat"
+	"github.com/luqmanarifin/kentang/logging"
+	"github.com/luqmanarifin/kentang/model"
+	"github.com/luqmanarifin/kentang/render"
+	"github.com/luqmanarifin/kentang/util"
+)
+
+const (
+	statDays   = 30
+	chartWeeks = 12
+	chartTop   = 10
+)
+
+func (h *Handler) handleStat(event *chat.Event, tokens []string) {
+	if len(tokens) > 2 {
+		return
+	}
+	source := event.Source
+	loc := h.location(event.Context(), source)
+	now := time.Now().In(loc)
+
+	if len(tokens) == 2 {
+		keyword := tokens[1]
+		dict, err := h.store.GetDictionaryByKeyword(event.Context(), source, keyword)
+		if err != nil || dict.Keyword != keyword {
+			h.reply(event, "Keyword "+keyword+" is not exists")
+			return
+		}
+		entries, err := h.store.GetEntriesByKeyword(event.Context(), source, keyword)
+		if err != nil {
+			slog.ErrorContext(event.Context(), "Cannot fetch entries", "keyword", keyword, logging.Err(err))
+			return
+		}
+		series := render.DailySeries(render.CountByDay(entries, loc), now, statDays)
+		total := strconv.Itoa(len(entries))
+		recent := strconv.Itoa(sum(series))
+		streak := strconv.Itoa(achievement.DailyStreak(entries, now, loc))
+		c := card.Card{
+			Title: keyword + " - " + dict.Description,
+			Rows: []card.Row{
+				{Label: "Total", Value: total},
+				{Label: "Last " + strconv.Itoa(statDays) + " days", Detail: render.Sparkline(series), Value: recent},
+				{Label: "Streak", Value: streak + " days"},
+			},
+		}
+		h.replyCard(event, c, keyword+" - "+dict.Description+
+			"\nTotal: "+total+
+			"\nLast "+strconv.Itoa(statDays)+" days: "+recent+
+			"\nStreak: "+streak+" days"+
+			"\n"+render.Sparkline(series), nil)
+		return
+	}
+
+	entries, err := h.store.GetMonthEntries(event.Context(), source)
+	if err != nil {
+		slog.ErrorContext(event.Context(), "Cannot fetch month entries", logging.Err(err))
+		return
+	}
+	if len(entries) == 0 {
+		h.reply(event, "No entries in the last "+strconv.Itoa(statDays)+" days")
+		return
+	}
+	series := render.DailySeries(render.CountByDay(entries, loc), now, statDays)
+	c := card.Card{Title: "Last " + strconv.Itoa(statDays) + " days: " + strconv.Itoa(len(entries))}
+	pairs := util.EntriesToSortedMap(entries)
+	positions := util.Positions(pairs)
+	for i, pair := range pairs {
+		c.Rows = append(c.Rows, card.Row{Rank: positions[i], Label: pair.Value, Value: strconv.Itoa(pair.Key)})
+	}
+	h.replyCard(event, c, "Last "+strconv.Itoa(statDays)+" days: "+strconv.Itoa(len(entries))+
+		"\n"+render.Sparkline(series)+
+		"\n\n"+topBars(entries, chartTop), nil)
+}
+
+func (h *Handler) handleChart(event *chat.Event, tokens []string) {
+	if len(tokens) > 2 {
+		return
+	}
+	source := event.Source
+	loc := h.location(event.Context(), source)
+	now := time.Now().In(loc)
+	from := now.AddDate(0, 0, -7*chartWeeks)
+
+	title := "Last " + strconv.Itoa(chartWeeks) + " weeks"
+	var entries []model.Entry
+	var err error
+	if len(tokens) == 2 {
+		keyword := tokens[1]
+		title = keyword + ", " + title
+		var all []model.Entry
+		all, err = h.store.GetEntriesByKeyword(event.Context(), source, keyword)
+		for _, e := range all {
+			if e.Timestamp.After(from) {
+				entries = append(entries, e)
+			}
+		}
+	} else {
+		entries, err = h.store.GetEntriesBetween(event.Context(), source, from, now)
+	}
+	if err != nil {
+		slog.ErrorContext(event.Context(), "Cannot fetch chart entries", logging.Err(err))
+		return
+	}
+	if len(entries) == 0 {
+		h.reply(event, "Nothing to chart")
+		return
+	}
+	if h.chartsEnabled() && event.Is(chat.PlatformLINE) {
+		if err := h.replyChartPNG(event, tokens, entries, loc); err == nil {
+			return
+		}
+		slog.WarnContext(event.Context(), "Cannot reply with the chart image, replying with text")
+	}
+
+	message := title + ": " + strconv.Itoa(len(entries)) +
+		"\n" + render.Heatmap(render.CountByDay(entries, loc), now, chartWeeks)
+	if len(tokens) == 1 {
+		message = message + "\n\n" + topBars(entries, chartTop)
+	}
+	h.reply(event, message)
+}
+
+// replyChartPNG draws the leaderboard, or the daily counts of a keyword, as
+// an image. Nothing is replied when it fails.
+func (h *Handler) replyChartPNG(event *chat.Event, tokens []string, entries []model.Entry, loc *time.Location) error {
+	now := time.Now().In(loc)
+	var png []byte
+	var err error
+	if len(tokens) == 2 {
+		series := render.DailySeries(render.CountByDay(entries, loc), now, statDays)
+		png, err = render.SeriesPNG(tokens[1]+", last "+strconv.Itoa(statDays)+" days", series, now)
+	} else {
+		labels, values := top(entries, chartTop)
+		png, err = render.BarChartPNG("Last "+strconv.Itoa(chartWeeks)+" weeks", labels, values)
+	}
+	if err != nil {
+		slog.ErrorContext(event.Context(), "Cannot render chart", logging.Err(err))
+		return err
+	}
+	return h.replyChart(event, png)
+}
+
+func topBars(entries []model.Entry, n int) string {
+	labels, values := top(entries, n)
+	return render.Bars(labels, values, 10)
+}
+
+// top returns the n most counted keywords and their counts.
+func top(entries []model.Entry, n int) ([]string, []int) {
+	pairs := util.EntriesToSortedMap(entries)
+	if len(pairs) > n {
+		pairs = pairs[:n]
+	}
+	var labels []string
+	var values []int
+	for _, pair := range pairs {
+		labels = append(labels, pair.Value)
+		values = append(values, pair.Key)
+	}
+	return labels, values
+}
+
+func sum(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/luqmanarifin/kentang/service"
+)
+
+// chartlessCache can't store charts, as when Redis is down.
+type chartlessCache struct{ *service.MemoryCache }
+
+func (chartlessCache) SetChart(ctx context.Context, id string, png []byte, ttl time.Duration) error {
+	return errors.New("connection refused")
+}
+
+func TestChartFallsBackToText(t *testing.T) {
+	h, api, send := newLineHandler(t, service.NewMemory(), chartlessCache{service.NewMemoryCache()})
+	h.baseURL = "https://kentang.example"
+
+	send(api.TextEvent("G1", "U1", "add telat terlambat"))
+	send(api.TextEvent("G1", "U1", "telat"))
+	got := send(api.TextEvent("G1", "U1", "chart"))
+	if !strings.Contains(got, "Last 12 weeks: 1") || !strings.Contains(got, "telat") {
+		t.Errorf("got %q, want the text chart", got)
+	}
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/luqmanarifin/kentang/chat"
+	"github.com/luqmanarifin/kentang/logging"
+)
+
+// eventTTL is how long handled event IDs are remembered. Platforms give up
+// redelivering well before that.
+const eventTTL = 24 * time.Hour
+
+// handleOnce handles event unless it was handled before, i.e. it's a
+// redelivery. The event is marked as handled as soon as handling it replied
+// or committed a change, which handling it again would repeat. Until then a
+// redelivery is handled again, so an event that failed or never finished
+// isn't lost. When the cache is down the event is handled anyway.
+func (h *Handler) handleOnce(event *chat.Event, handle func(event *chat.Event)) {
+	if event.EventID == "" {
+		handle(event)
+		return
+	}
+	marked, err := h.cache.EventMarked(event.Context(), event.EventID)
+	if err != nil {
+		slog.ErrorContext(event.Context(), "Cannot check event", logging.Err(err))
+	} else if marked {
+		slog.InfoContext(event.Context(), "Skipping redelivered event")
+		return
+	}
+
+	o := &outcome{mark: func(ctx context.Context) {
+		// marked even when the event runs out of time right after
+		ctx = context.WithoutCancel(ctx)
+		if _, err := h.cache.MarkEvent(ctx, event.EventID, eventTTL); err != nil {
+			slog.ErrorContext(ctx, "Cannot mark event", logging.Err(err))
+		}
+	}}
+	handle(event.WithContext(context.WithValue(event.Context(), outcomeKey{}, o)))
+	if replied, committed := o.get(); !replied && !committed {
+		slog.DebugContext(event.Context(), "Event had no effect, leaving it to a redelivery")
+	}
+}
+
+// outcome is what handling an event did that handling it again would repeat:
+// replying, or committing a change like counting an entry. mark is called on
+// the first of them.
+type outcome struct {
+	mark func(ctx context.Context)
+
+	mu        sync.Mutex
+	replied   bool
+	committed bool
+}
+
+type outcomeKey struct{}
+
+func (o *outcome) record(ctx context.Context, replied, committed bool) {
+	o.mu.Lock()
+	first := !o.replied && !o.committed
+	o.replied = o.replied || replied
+	o.committed = o.committed || committed
+	o.mu.Unlock()
+	if first {
+		o.mark(ctx)
+	}
+}
+
+func (o *outcome) get() (replied, committed bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.replied, o.committed
+}
+
+// replied records that the event handled in ctx was replied to.
+func replied(ctx context.Context) {
+	if o, ok := ctx.Value(outcomeKey{}).(*outcome); ok {
+		o.record(ctx, true, false)
+	}
+}
+
+// committed records that the event handled in ctx changed what is stored.
+func committed(ctx context.Context) {
+	if o, ok := ctx.Value(outcomeKey{}).(*outcome); ok {
+		o.record(ctx, false, true)
+	}
+}
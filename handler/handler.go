@@ -1,63 +1,113 @@
 package handler
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
-	"os"
 	"strconv"
 	"strings"
-
-	"github.com/line/line-bot-sdk-go/linebot"
+	"time"
+
+	"github.com/line/line-bot-sdk-go/v7/linebot"
+	"github.com/luqmanarifin/kentang/action"
+	"github.com/luqmanarifin/kentang/card"
+	"github.com/luqmanarifin/kentang/chat"
+	"github.com/luqmanarifin/kentang/config"
+	"github.com/luqmanarifin/kentang/logging"
+	"github.com/luqmanarifin/kentang/metrics"
 	"github.com/luqmanarifin/kentang/model"
+	"github.com/luqmanarifin/kentang/queue"
+	"github.com/luqmanarifin/kentang/redact"
+	"github.com/luqmanarifin/kentang/render"
 	"github.com/luqmanarifin/kentang/service"
+	"github.com/luqmanarifin/kentang/tracing"
 	"github.com/luqmanarifin/kentang/util"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 )
 
 var (
 	lineGreetingMessage = `Hi! Kentang's here. Add this bot to your group and count your friends koplaqueness!`
 	lineHelpString      = `Here are available commands:
 - add [keyword] [desc]
+- add @someone [keyword] [desc]
+- @someone [keyword] -> Count for them
 - remove [keyword]
 - list
 - [keyword] -> Increase count
-- highscore -> This month
-- stat
+- highscore [week|all] -> This month
+- stat [keyword]
+- chart [keyword] -> Last 12 weeks
 - reset -> Reset all
+- timezone [zone] -> e.g. Asia/Jakarta
+- recap [daily|weekly|monthly|off]
+- style [text|flex] -> How lists look
+- season -> This season
+- season end -> Archive this season
+- season auto [on|off] -> End season monthly
+- history [season]
+- champions
+- badges [keyword]
+- add-sticker [keyword] -> Then send the sticker
+- remove-sticker [keyword]
+- bind [image|video|audio|file|location] [command]
+- unbind [image|video|audio|file|location]
+- bindings
 - help`
 )
 
 type Handler struct {
 	bot   *linebot.Client
-	mysql *service.MySQL
-	redis *service.Redis
+	store service.Store
+	cache service.Cache
+
+	platforms map[string]chat.Platform
+	telegram  *chat.Telegram
+	slack     *chat.Slack
+	discord   *chat.Discord
+
+	queue *queue.Queue
+	// ctx is the parent of every event's context, cancelled when the queue
+	// can't be drained in time
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	baseURL    string
+	secret     string
+	adminToken string
 }
 
-func NewHandler(channelSecret, channelToken string) *Handler {
-	bot, err := linebot.New(
-		os.Getenv("CHANNEL_SECRET"),
-		os.Getenv("CHANNEL_TOKEN"),
-	)
+// NewHandler connects to LINE, MySQL, Redis and the other platforms set in
+// c.
+func NewHandler(c config.Config) (*Handler, error) {
+	bot, err := linebot.New(c.LINE.ChannelSecret, c.LINE.ChannelToken,
+		linebot.WithHTTPClient(&http.Client{Transport: lineTransport}))
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-
-	opt := service.MySQLOption{
-		User:     os.Getenv("MYSQL_USER"),
-		Password: os.Getenv("MYSQL_PASSWORD"),
-		Host:     os.Getenv("MYSQL_HOST"),
-		Port:     os.Getenv("MYSQL_PORT"),
-		Database: os.Getenv("MYSQL_DATABASE"),
-		Charset:  os.Getenv("MYSQL_CHARSET"),
+	platforms := []chat.Platform{&chat.Line{Client: bot}}
+	if c.Telegram.Token != "" && c.Telegram.Secret != "" {
+		platforms = append(platforms, chat.NewTelegram(c.Telegram.Token, c.Telegram.Secret, ""))
 	}
-	mysql, err := service.NewMySQL(opt)
-	if err != nil {
-		log.Fatalf("%s", err.Error())
+	if c.Slack.SigningSecret != "" {
+		platforms = append(platforms, chat.NewSlack(c.Slack.SigningSecret, c.Slack.BotToken, ""))
+	}
+	if c.Discord.PublicKey != "" {
+		discord, err := chat.NewDiscord(c.Discord.ApplicationID, c.Discord.PublicKey, c.Discord.BotToken, "")
+		if err != nil {
+			return nil, err
+		}
+		platforms = append(platforms, discord)
 	}
 
-	redisUrl, err := url.Parse(os.Getenv("REDIS_URL"))
+	redisUrl, err := url.Parse(c.Redis.URL)
 	if err != nil {
-		log.Fatalf("%s", err.Error())
+		// the error would quote the URL, password included
+		return nil, errors.New("invalid redis url")
 	}
 	password, _ := redisUrl.User.Password()
 	redisOpt := service.RedisOption{
@@ -66,56 +116,151 @@ func NewHandler(channelSecret, channelToken string) *Handler {
 		Password: password,
 		Database: 0,
 	}
-	log.Printf("redis opt: %v", redisOpt)
+
+	opt := service.MySQLOption{
+		User:     c.MySQL.User,
+		Password: c.MySQL.Password,
+		Host:     c.MySQL.Host,
+		Port:     c.MySQL.Port,
+		Database: c.MySQL.Database,
+		Charset:  c.MySQL.Charset,
+	}
+	mysql, err := service.NewMySQL(opt)
+	if err != nil {
+		return nil, fmt.Errorf("mysql %s: %v", opt, err)
+	}
 	redis, err := service.NewRedis(redisOpt)
 	if err != nil {
-		log.Fatalf("%s", err.Error())
+		mysql.Close()
+		return nil, fmt.Errorf("redis %s: %v", redisOpt, err)
+	}
+
+	h := New(mysql, redis, platforms...)
+	h.baseURL = c.BaseURL
+	h.secret = c.SigningSecret
+	h.adminToken = c.Admin.Token
+	return h, nil
+}
+
+// New makes a handler keeping its data in store and cache, answering on the
+// given platforms.
+func New(store service.Store, cache service.Cache, platforms ...chat.Platform) *Handler {
+	h := &Handler{
+		store:     store,
+		cache:     cache,
+		platforms: make(map[string]chat.Platform),
+		queue:     queue.New(queueWorkers, queueSize),
+	}
+	h.ctx, h.cancel = context.WithCancel(context.Background())
+	for _, platform := range platforms {
+		h.platforms[platform.Name()] = platform
+		switch p := platform.(type) {
+		case *chat.Line:
+			h.bot = p.Client
+		case *chat.Telegram:
+			h.telegram = p
+		case *chat.Slack:
+			h.slack = p
+		case *chat.Discord:
+			h.discord = p
+		}
 	}
+	return h
+}
 
-	return &Handler{bot: bot, mysql: mysql, redis: redis}
+// Close closes the store and cache connections. Call it once no event is
+// handled anymore.
+func (h *Handler) Close() error {
+	h.cancel()
+	var err error
+	for _, c := range []interface{}{h.store, h.cache} {
+		if closer, ok := c.(io.Closer); ok {
+			if cerr := closer.Close(); cerr != nil {
+				err = cerr
+			}
+		}
+	}
+	return err
 }
 
 func (h *Handler) Index(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("cok"))
 }
 
-//Healthz - health check
-func (h *Handler) Healthz(w http.ResponseWriter, r *http.Request) {
-	log.Printf("health\n")
-	w.Header().Set("Content-Type", "application/json")
-	w.Write([]byte("{\"status\":\"ok\"}"))
+func (h *Handler) reply(event *chat.Event, messages ...string) error {
+	return h.replyQuick(event, nil, messages...)
 }
 
-func (h *Handler) log(format string, args ...interface{}) {
-	log.Printf("[LINE] "+format, args...)
+// replyQuick replies with text messages, attaching the quick reply buttons to
+// the last one. Quick replies are only shown on LINE.
+func (h *Handler) replyQuick(event *chat.Event, quick *linebot.QuickReplyItems, messages ...string) error {
+	if quick == nil || !event.Is(chat.PlatformLINE) {
+		err := event.Reply(messages...)
+		if err != nil {
+			slog.ErrorContext(event.Context(), "Cannot reply", logging.Err(err))
+		} else {
+			replied(event.Context())
+		}
+		return err
+	}
+	lineMessages := chat.TextMessages(messages...)
+	if len(lineMessages) > 0 {
+		last := len(lineMessages) - 1
+		lineMessages[last] = lineMessages[last].WithQuickReplies(quick)
+	}
+	return h.replyMessages(event, lineMessages...)
 }
 
-func (h *Handler) reply(event *linebot.Event, messages ...string) error {
-	var lineMessages []linebot.Message
-	for _, message := range messages {
-		lineMessages = append(lineMessages, linebot.NewTextMessage(message))
-	}
-	_, err := h.bot.ReplyMessage(event.ReplyToken, lineMessages...).Do()
+// replyMessages replies with LINE messages, so event must come from LINE.
+func (h *Handler) replyMessages(event *chat.Event, lineMessages ...linebot.SendingMessage) error {
+	_, err := h.bot.ReplyMessage(event.ReplyToken, lineMessages...).WithContext(event.Context()).Do()
 	if err != nil {
-		h.log("Error replying to %+v: %s", event.Source, err.Error())
+		slog.ErrorContext(event.Context(), "Cannot reply", logging.Err(err))
+	} else {
+		replied(event.Context())
 	}
 	return err
 }
 
-func (h *Handler) push(to string, messages ...string) error {
-	var lineMessages []linebot.Message
-	for _, message := range messages {
-		lineMessages = append(lineMessages, linebot.NewTextMessage(message))
+func (h *Handler) push(ctx context.Context, to string, messages ...string) error {
+	platform, ok := h.platforms[chat.PlatformOf(to)]
+	if !ok {
+		slog.WarnContext(ctx, "No platform to push to", "to", to)
+		return nil
 	}
-	_, err := h.bot.PushMessage(to, lineMessages...).Do()
+	err := platform.Push(ctx, to, messages...)
 	if err != nil {
-		h.log("Error pushing to %s: %s", to, err.Error())
+		slog.ErrorContext(ctx, "Cannot push", "to", to, logging.Err(err))
 	}
 	return err
 }
 
+// lineEvent turns a LINE webhook event into a chat event.
+func (h *Handler) lineEvent(event *linebot.Event) *chat.Event {
+	e := &chat.Event{
+		Platform:   h.platforms[chat.PlatformLINE],
+		Source:     util.LineEventSourceToReplyString(event.Source),
+		UserID:     event.Source.UserID,
+		ReplyToken: event.ReplyToken,
+		EventID:    event.WebhookEventID,
+	}
+	if message, ok := event.Message.(*linebot.TextMessage); ok {
+		e.Text = message.Text
+		// webhooks sent before webhookEventId existed only have the message ID
+		if e.EventID == "" && message.ID != "" {
+			e.EventID = "message:" + message.ID
+		}
+	}
+	return e
+}
+
+// Callback verifies LINE webhook events and queues them, answering LINE
+// before any of them is handled. When the queue is full it answers 503, and
+// LINE delivers the events again if redelivery is on.
 func (h *Handler) Callback(w http.ResponseWriter, r *http.Request) {
+	_, span := tracing.Start(r.Context(), "line verify_signature")
 	events, err := h.bot.ParseRequest(r)
+	tracing.End(span, err)
 	if err != nil {
 		if err == linebot.ErrInvalidSignature {
 			w.WriteHeader(400)
@@ -124,152 +269,286 @@ func (h *Handler) Callback(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
+	status := 200
+	ctx := tracing.Inherit(context.Background(), r.Context())
 	for _, event := range events {
-		h.log("[EVENT][%s] Source: %#v", event.Type, event.Source)
-		switch event.Type {
-
-		case linebot.EventTypeJoin:
-			fallthrough
-		case linebot.EventTypeFollow:
-			h.handleFollow(event)
-
-		case linebot.EventTypeLeave:
-			fallthrough
-		case linebot.EventTypeUnfollow:
-			fallthrough
-
-		case linebot.EventTypeMessage:
-			switch message := event.Message.(type) {
-			case *linebot.TextMessage:
-				h.handleTextMessage(event, message)
-			}
+		event := event
+		if !h.enqueue(util.LineEventSourceToReplyString(event.Source), func() { h.handleLineEvent(ctx, event) }) {
+			status = 503
 		}
 	}
+	w.WriteHeader(status)
+}
+
+func (h *Handler) handleLineEvent(ctx context.Context, event *linebot.Event) {
+	metrics.WebhookEvents.WithLabelValues(chat.PlatformLINE, string(event.Type)).Inc()
+	e, done := h.startEvent(h.lineEvent(event).WithContext(ctx))
+	defer done()
+	slog.DebugContext(e.Context(), "Received event", "type", event.Type)
+	h.handleOnce(e, func(e *chat.Event) {
+		h.dispatchLineEvent(e, event)
+	})
+}
+
+// dispatchLineEvent handles a LINE event by its type.
+func (h *Handler) dispatchLineEvent(e *chat.Event, event *linebot.Event) {
+	switch event.Type {
+
+	case linebot.EventTypeJoin:
+		fallthrough
+	case linebot.EventTypeFollow:
+		h.handleJoin(e)
+
+	case linebot.EventTypeLeave:
+		fallthrough
+	case linebot.EventTypeUnfollow:
+		h.handleLeave(e)
+
+	case linebot.EventTypeMemberJoined:
+		h.handleMemberJoined(e, event.Joined)
+	case linebot.EventTypeMemberLeft:
+		h.handleMemberLeft(e.Context(), event.Left)
+
+	case linebot.EventTypeMessage:
+		switch message := event.Message.(type) {
+		case *linebot.TextMessage:
+			h.handleTextMessage(e, message)
+		case *linebot.StickerMessage:
+			h.handleStickerMessage(e, message)
+		default:
+			h.handleBoundMessage(e, message)
+		}
+
+	case linebot.EventTypePostback:
+		h.handlePostback(e, event.Postback)
+	}
 }
 
-func (h *Handler) handleFollow(event *linebot.Event) {
+func (h *Handler) handleFollow(event *chat.Event) {
 	message := lineGreetingMessage + "\n\n" + lineHelpString
 	h.reply(event, message)
 }
 
-func (h *Handler) handleTextMessage(event *linebot.Event, message *linebot.TextMessage) {
-	source := util.LineEventSourceToReplyString(event.Source)
-	log.Printf("Received message from %s: %s", source, message.Text)
+func (h *Handler) handleTextMessage(event *chat.Event, message *linebot.TextMessage) {
+	slog.InfoContext(event.Context(), "Received message", "text", redact.Text(message.Text))
 
-	tokens := strings.Split(message.Text, " ")
-	switch strings.ToLower(tokens[0]) {
+	text, handled := h.handleMention(event, message)
+	if handled {
+		return
+	}
+	h.handleCommand(event, text)
+}
+
+func (h *Handler) handleCommand(event *chat.Event, text string) {
+	tokens := strings.Split(text, " ")
+	var handle func(*chat.Event, []string)
+	command := strings.ToLower(tokens[0])
+	switch command {
 	case "add":
-		h.handleAdd(event, tokens)
+		handle = h.handleAdd
 	case "remove":
-		h.handleRemove(event, tokens)
+		handle = h.handleRemove
 	case "list":
-		h.handleList(event, tokens)
+		handle = h.handleList
 	case "highscore":
-		h.handleHighscore(event, tokens)
+		handle = h.handleHighscore
 	case "stat":
-		h.handleStat(event, tokens)
+		handle = h.handleStat
+	case "chart":
+		handle = h.handleChart
 	case "reset":
-		h.handleReset(event, tokens)
+		handle = h.handleReset
 	case "help":
-		h.handleHelp(event, tokens)
+		handle = h.handleHelp
 	case "profile":
-		h.handleProfile(event, tokens)
+		handle = h.handleProfile
+	case "timezone":
+		handle = h.handleTimezone
+	case "recap":
+		handle = h.handleRecap
+	case "style":
+		handle = h.handleStyle
+	case "season":
+		handle = h.handleSeason
+	case "history":
+		handle = h.handleHistory
+	case "champions":
+		handle = h.handleChampions
+	case "badges":
+		handle = h.handleBadges
+	case "add-sticker":
+		handle = h.handleAddSticker
+	case "remove-sticker":
+		handle = h.handleRemoveSticker
+	case "bind":
+		handle = h.handleBind
+	case "unbind":
+		handle = h.handleUnbind
+	case "bindings":
+		handle = h.handleBindings
 	default:
-		h.handleKeyword(event, tokens)
+		command, handle = "keyword", h.handleKeyword
+	}
+	ctx, span := tracing.Start(event.Context(), "command "+command)
+	defer span.End()
+	ctx, failures := logging.CountErrors(logging.With(ctx, "command", command))
+	handle(event.WithContext(ctx), tokens)
+
+	outcome := "ok"
+	if failures() > 0 {
+		outcome = "error"
+		span.SetStatus(codes.Error, "logged errors")
+	}
+	metrics.Commands.WithLabelValues(command, outcome).Inc()
+}
+
+// startEvent returns event with the fields logged along everything done for
+// it, a deadline for handling it and a span joining the trace of the webhook
+// it came in. Call done once it's handled.
+func (h *Handler) startEvent(event *chat.Event) (_ *chat.Event, done func()) {
+	platform := platformName(event)
+	ctx, cancel := context.WithTimeout(tracing.Inherit(h.ctx, event.Context()), eventTimeout)
+	ctx, span := tracing.Start(ctx, "event "+platform,
+		attribute.String("event.id", event.EventID),
+		attribute.String("event.source", event.Source))
+	ctx = logging.With(ctx,
+		"platform", platform,
+		"event_id", event.EventID,
+		"source", event.Source,
+		"user", event.UserID,
+	)
+	return event.WithContext(ctx), func() {
+		span.End()
+		cancel()
+	}
+}
+
+// lineTransport times and traces the calls to the LINE API.
+var lineTransport = tracing.Transport(metrics.Transport(http.DefaultTransport), func(r *http.Request) string {
+	return "line " + metrics.LINECall(r.URL.Path)
+})
+
+// platformName names the platform event comes from.
+func platformName(event *chat.Event) string {
+	if event.Platform != nil {
+		return event.Platform.Name()
 	}
+	return chat.PlatformOf(event.Source)
 }
 
-func (h *Handler) handleAdd(event *linebot.Event, tokens []string) {
+func (h *Handler) handleAdd(event *chat.Event, tokens []string) {
 	if len(tokens) != 3 {
 		return
 	}
 	keyword := tokens[1]
 	desc := tokens[2]
-	source := util.LineEventSourceToReplyString(event.Source)
+	source := event.Source
 
-	val, err := h.redis.GetKeyword(source, keyword)
+	val, err := h.cache.GetKeyword(event.Context(), source, keyword)
 
 	if err == nil && val != util.NOT_EXIST {
 		h.reply(event, keyword+" is already here before.")
 		return
 	}
 
-	dict, err := h.mysql.GetDictionaryByKeyword(source, keyword)
+	dict, err := h.store.GetDictionaryByKeyword(event.Context(), source, keyword)
 	if dict.Keyword == keyword {
 		h.reply(event, keyword+" is already here before.")
 		return
 	}
-	err = h.mysql.CreateDictionary(&model.Dictionary{
+	err = h.store.CreateDictionary(event.Context(), &model.Dictionary{
 		Source:      source,
 		Keyword:     keyword,
 		Description: desc,
-		Creator:     event.Source.UserID,
+		Creator:     event.UserID,
 	})
 	if err != nil {
-		log.Printf("Error when adding %s in %s\n", keyword, source)
+		slog.ErrorContext(event.Context(), "Cannot add keyword", "keyword", keyword, logging.Err(err))
 		return
 	}
+	committed(event.Context())
 	h.reply(event, keyword+" has been added")
 
-	err = h.redis.AddKeyword(source, keyword, desc)
+	err = h.cache.AddKeyword(event.Context(), source, keyword, desc)
 	if err != nil {
-		log.Printf("Error when adding cache %s in %s\n", keyword, source)
+		slog.ErrorContext(event.Context(), "Cannot cache keyword", "keyword", keyword, logging.Err(err))
 		return
 	}
 }
 
-func (h *Handler) handleRemove(event *linebot.Event, tokens []string) {
+func (h *Handler) handleRemove(event *chat.Event, tokens []string) {
+	if len(tokens) == 1 {
+		h.pickKeywordToRemove(event)
+		return
+	}
 	if len(tokens) != 2 {
 		return
 	}
 	keyword := tokens[1]
-	source := util.LineEventSourceToReplyString(event.Source)
+	source := event.Source
 
-	desc, err := h.redis.GetKeyword(source, keyword)
+	desc, err := h.cache.GetKeyword(event.Context(), source, keyword)
 	if err == nil && desc == util.NOT_EXIST {
 		h.reply(event, "Keyword "+keyword+" is not exists")
 		return
 	}
 
-	dict, err := h.mysql.GetDictionaryByKeyword(source, keyword)
+	dict, err := h.store.GetDictionaryByKeyword(event.Context(), source, keyword)
 	if err != nil {
-		log.Printf("Error when getting info when deleting %s in %s\n", keyword, source)
+		slog.ErrorContext(event.Context(), "Cannot fetch keyword to remove", "keyword", keyword, logging.Err(err))
 		return
 	}
 	if dict.Keyword != keyword {
 		h.reply(event, "Keyword "+keyword+" is not exists")
 		return
 	}
-	if event.Source.UserID != dict.Creator {
+	if event.UserID != dict.Creator {
 		h.reply(event, "Only the creator can remove it")
 		return
 	}
-	err = h.mysql.RemoveDictionary(&dict)
+	err = h.removeKeyword(event.Context(), dict)
 	if err != nil {
-		log.Printf("Error when deleting %s in %s\n", keyword, source)
-		return
-	}
-	err = h.mysql.RemoveEntryByKeyword(source, keyword)
-	if err != nil {
-		log.Printf("Error when deleting entries %s in %s", keyword, source)
+		slog.ErrorContext(event.Context(), "Cannot remove keyword", "keyword", keyword, logging.Err(err))
 		return
 	}
 	h.reply(event, "Keyword "+keyword+" removed")
 
-	err = h.redis.RemoveKeyword(source, keyword)
+	err = h.cache.RemoveKeyword(event.Context(), source, keyword)
 	if err != nil {
-		log.Printf("Error when deleting cache %s in %s\n", keyword, source)
+		slog.ErrorContext(event.Context(), "Cannot uncache keyword", "keyword", keyword, logging.Err(err))
 	}
 }
 
-func (h *Handler) handleList(event *linebot.Event, tokens []string) {
+// removeKeyword removes a dictionary with its entries. A plain keyword also
+// loses its badges and sticker bindings.
+func (h *Handler) removeKeyword(ctx context.Context, dict model.Dictionary) error {
+	if err := h.store.RemoveDictionary(ctx, &dict); err != nil {
+		return err
+	}
+	committed(ctx)
+	if err := h.store.RemoveEntryByTarget(ctx, dict.Source, dict.Keyword, dict.Target); err != nil {
+		return fmt.Errorf("entries: %v", err)
+	}
+	if dict.Target != "" {
+		return nil
+	}
+	if err := h.store.RemoveBadgeByKeyword(ctx, dict.Source, dict.Keyword); err != nil {
+		return fmt.Errorf("badges: %v", err)
+	}
+	if err := h.store.RemoveBindingByCommand(ctx, dict.Source, bindingSticker, dict.Keyword); err != nil {
+		return fmt.Errorf("sticker bindings: %v", err)
+	}
+	return nil
+}
+
+func (h *Handler) handleList(event *chat.Event, tokens []string) {
 	if len(tokens) != 1 {
 		return
 	}
-	source := util.LineEventSourceToReplyString(event.Source)
-	dicts, err := h.mysql.GetAllDictionaries(source)
+	source := event.Source
+	dicts, err := h.store.GetAllDictionaries(event.Context(), source)
 	if err != nil {
-		log.Printf("Error when fetching dictionaries for %s\n", source)
+		slog.ErrorContext(event.Context(), "Cannot fetch keywords", logging.Err(err))
 		return
 	}
 	if len(dicts) == 0 {
@@ -277,153 +556,303 @@ func (h *Handler) handleList(event *linebot.Event, tokens []string) {
 		return
 	}
 	message := "Keywords:"
+	c := card.Card{Title: "Keywords"}
 	for i, dict := range dicts {
-		message = message + "\n" + strconv.Itoa(i+1) + ". " + dict.Keyword + ": " + dict.Description + " (" + h.getProfileName(dict.Creator) + ")"
-	}
-	h.reply(event, message)
+		name, picture := h.getProfile(event.Context(), dict.Creator)
+		keyword := dict.Keyword
+		if dict.Target != "" {
+			keyword = "@" + h.getProfileName(event.Context(), dict.Target) + " " + keyword
+		}
+		message = message + "\n" + strconv.Itoa(i+1) + ". " + keyword + ": " + dict.Description + " (" + name + ")"
+		c.Rows = append(c.Rows, card.Row{
+			Label:    keyword,
+			Detail:   dict.Description + " · " + name,
+			ImageURL: picture,
+		})
+	}
+	h.replyCard(event, c, message, nil)
 }
 
-func (h *Handler) handleHighscore(event *linebot.Event, tokens []string) {
-	if len(tokens) != 1 {
+func (h *Handler) handleHighscore(event *chat.Event, tokens []string) {
+	if len(tokens) > 2 {
+		return
+	}
+	period := highscoreMonth
+	if len(tokens) == 2 {
+		period = strings.ToLower(tokens[1])
+	}
+	title, ok := highscoreTitles[period]
+	if !ok {
 		return
 	}
-	source := util.LineEventSourceToReplyString(event.Source)
-	entries, err := h.mysql.GetMonthEntries(source)
+
+	source := event.Source
+	var entries []model.Entry
+	var err error
+	switch period {
+	case highscoreWeek:
+		entries, err = h.store.GetWeekEntries(event.Context(), source)
+	case highscoreAll:
+		entries, err = h.store.GetAllEntries(event.Context(), source)
+	default:
+		entries, err = h.store.GetMonthEntries(event.Context(), source)
+	}
 	if err != nil {
-		log.Printf("Error in fetching highscore")
+		slog.ErrorContext(event.Context(), "Cannot fetch highscore entries", logging.Err(err))
 		return
 	}
-	p := util.EntriesToSortedMap(entries)
-	if len(p) == 0 {
+	if len(entries) == 0 {
 		h.reply(event, "No highscore")
 		return
 	}
-	message := "Highscore:"
-	for _, pair := range p {
-		dict, err := h.mysql.GetDictionaryByKeyword(source, pair.Value)
+	c, message, err := h.highscore(event.Context(), source, title+":", entries)
+	if err != nil {
+		slog.ErrorContext(event.Context(), "Cannot rank highscore", logging.Err(err))
+		return
+	}
+	c.Title = title
+	topKeyword := ""
+	if len(c.Rows) > 0 {
+		topKeyword = c.Rows[0].Label
+	}
+	h.replyCard(event, c, message, h.highscoreQuickReplies(event, period, topKeyword))
+}
+
+func (h *Handler) highscoreMessage(ctx context.Context, source, title string, entries []model.Entry) (string, error) {
+	_, message, err := h.highscore(ctx, source, title, entries)
+	return message, err
+}
+
+// highscore ranks the keywords of entries, both as a card and as text.
+func (h *Handler) highscore(ctx context.Context, source, title string, entries []model.Entry) (card.Card, string, error) {
+	message := title
+	c := card.Card{Title: title}
+	pairs := util.EntriesToSortedMap(entries)
+	positions := util.Positions(pairs)
+	for i, pair := range pairs {
+		dict, err := h.store.GetDictionaryByKeyword(ctx, source, pair.Value)
 		if err != nil {
-			log.Printf("error when fetching desc")
-			return
+			return card.Card{}, "", err
 		}
-		message = message + "\n" + pair.Value + " - " + dict.Description + " : " + strconv.Itoa(pair.Key)
+		message = message + "\n" + pair.Value + " - " + dict.Description + " : " + strconv.Itoa(pair.Key) + " " + render.Bar(pair.Key, pairs[0].Key, 8)
+		c.Rows = append(c.Rows, card.Row{
+			Rank:   positions[i],
+			Label:  pair.Value,
+			Detail: dict.Description,
+			Value:  strconv.Itoa(pair.Key),
+		})
 	}
-	h.reply(event, message)
+
+	people := h.peopleHighscore(ctx, source, entries)
+	if len(people) > 0 {
+		message = message + "\nPeople:"
+	}
+	for _, row := range people {
+		message = message + "\n" + row.Label + " - " + row.Detail + " : " + row.Value
+	}
+	c.Rows = append(c.Rows, people...)
+	return c, message, nil
+}
+
+// peopleHighscore ranks the keywords counted against mentioned users.
+func (h *Handler) peopleHighscore(ctx context.Context, source string, entries []model.Entry) []card.Row {
+	m := make(map[string]int)
+	for _, entry := range entries {
+		if entry.Target != "" {
+			m[entry.Target+" "+entry.Keyword]++
+		}
+	}
+	pairs := util.MapToSortedPairs(m)
+	positions := util.Positions(pairs)
+	var rows []card.Row
+	for i, pair := range pairs {
+		parts := strings.SplitN(pair.Value, " ", 2)
+		target, keyword := parts[0], parts[1]
+		dict, err := h.store.GetDictionaryByTarget(ctx, source, keyword, target)
+		if err != nil {
+			slog.ErrorContext(ctx, "Cannot fetch person keyword", "keyword", keyword, "target", target, logging.Err(err))
+		}
+		name, picture := h.getProfile(ctx, target)
+		rows = append(rows, card.Row{
+			Rank:     positions[i],
+			Label:    "@" + name + " " + keyword,
+			Detail:   dict.Description,
+			Value:    strconv.Itoa(pair.Key),
+			ImageURL: picture,
+		})
+	}
+	return rows
 }
 
-func (h *Handler) handleStat(event *linebot.Event, tokens []string) {
+func (h *Handler) handleReset(event *chat.Event, tokens []string) {
+	// other platforms have no buttons, the confirmation is typed instead
+	if !event.Is(chat.PlatformLINE) {
+		if len(tokens) == 2 && strings.ToLower(tokens[1]) == "confirm" {
+			h.reset(event)
+			return
+		}
+		h.reply(event, "Remove all keywords and their counts? This can't be undone. Send \"reset confirm\" to go on.")
+		return
+	}
 	if len(tokens) != 1 {
 		return
 	}
+	nonce, err := newNonce()
+	if err != nil {
+		slog.ErrorContext(event.Context(), "Cannot make reset nonce", logging.Err(err))
+		return
+	}
+	h.replyQuick(event, quickReplies(
+		h.postbackButton(event, "Yes, reset", "reset", action.New(actionReset, nonce)),
+		h.postbackButton(event, "Cancel", "cancel", action.New(actionCancel)),
+	), "Remove all keywords and their counts? This can't be undone.")
 }
 
-func (h *Handler) handleReset(event *linebot.Event, tokens []string) {
-	if len(tokens) != 1 {
+func (h *Handler) reset(event *chat.Event) {
+	source := event.Source
+	err := h.store.RemoveDictionaryBySource(event.Context(), source)
+	if err != nil {
+		slog.ErrorContext(event.Context(), "Cannot reset keywords", logging.Err(err))
+		return
+	}
+	committed(event.Context())
+	err = h.store.RemoveEntryBySource(event.Context(), source)
+	if err != nil {
+		slog.ErrorContext(event.Context(), "Cannot reset entries", logging.Err(err))
 		return
 	}
-	source := util.LineEventSourceToReplyString(event.Source)
-	err := h.mysql.RemoveDictionaryBySource(source)
+	err = h.store.RemoveBadgeBySource(event.Context(), source)
 	if err != nil {
-		log.Printf("Error in resetting dictionary in %s", source)
+		slog.ErrorContext(event.Context(), "Cannot reset badges", logging.Err(err))
 		return
 	}
-	err = h.mysql.RemoveEntryBySource(source)
+	err = h.store.RemoveBindingBySource(event.Context(), source)
 	if err != nil {
-		log.Printf("Error in resetting source in %s", source)
+		slog.ErrorContext(event.Context(), "Cannot reset bindings", logging.Err(err))
 		return
 	}
 	h.reply(event, "All cleared up.")
 
-	err = h.redis.RemoveAllKeyword(source)
+	err = h.cache.RemoveAllKeyword(event.Context(), source)
 	if err != nil {
-		log.Printf("Error in resetting cache source in %s", source)
+		slog.ErrorContext(event.Context(), "Cannot reset cache", logging.Err(err))
 		return
 	}
 }
 
-func (h *Handler) handleHelp(event *linebot.Event, tokens []string) {
+func (h *Handler) handleHelp(event *chat.Event, tokens []string) {
 	if len(tokens) != 1 {
 		return
 	}
 	h.reply(event, lineHelpString)
 }
 
-func (h *Handler) handleProfile(event *linebot.Event, tokens []string) {
+func (h *Handler) handleProfile(event *chat.Event, tokens []string) {
 	if len(tokens) != 1 {
 		return
 	}
-	source := event.Source
-	if source.UserID != "" {
-		profile, err := h.bot.GetProfile(source.UserID).Do()
-		if err != nil {
-			h.reply(event, "Add me first :)))")
-			return
-		}
-		if _, err := h.bot.ReplyMessage(
-			event.ReplyToken,
-			linebot.NewTextMessage("Display name: "+profile.DisplayName),
-			linebot.NewTextMessage("Status message: "+profile.StatusMessage),
-		).Do(); err != nil {
-			return
-		}
-	} else {
+	if event.UserID == "" {
 		h.reply(event, "Bot can't use profile API without user ID")
+		return
 	}
+	if !event.Is(chat.PlatformLINE) {
+		h.reply(event, "Display name: "+h.getProfileName(event.Context(), event.UserID))
+		return
+	}
+	profile, err := h.bot.GetProfile(event.UserID).WithContext(event.Context()).Do()
+	if err != nil {
+		h.reply(event, "Add me first :)))")
+		return
+	}
+	h.reply(event,
+		"Display name: "+profile.DisplayName,
+		"Status message: "+profile.StatusMessage,
+	)
 }
 
-func (h *Handler) handleKeyword(event *linebot.Event, tokens []string) {
+func (h *Handler) handleKeyword(event *chat.Event, tokens []string) {
 	if len(tokens) != 1 {
 		return
 	}
 	keyword := tokens[0]
-	source := util.LineEventSourceToReplyString(event.Source)
+	source := event.Source
 
-	// find keyword on redis first
-	ret, err := h.redis.GetKeyword(source, keyword)
+	// find keyword on cache first
+	ret, err := h.cache.GetKeyword(event.Context(), source, keyword)
 	if ret == util.NOT_EXIST {
-		log.Printf("%s is NOT exist in %s, based on cache", keyword, source)
+		slog.DebugContext(event.Context(), "Keyword doesn't exist, based on cache", "keyword", keyword)
 		return
 	} else if err == nil {
-		log.Printf("%s is exist in %s, based on cache", keyword, source)
+		slog.DebugContext(event.Context(), "Keyword exists, based on cache", "keyword", keyword)
 		h.addEntry(event, source, keyword, ret)
 		return
 	}
 
-	// find keyword on mysql
-	dict, err := h.mysql.GetDictionaryByKeyword(source, keyword)
+	// find keyword on store
+	dict, err := h.store.GetDictionaryByKeyword(event.Context(), source, keyword)
 	if err != nil || dict.Keyword != keyword {
-		h.redis.RemoveKeyword(source, keyword)
-		log.Printf("Can't found keyword %s in %s, found %s\n", keyword, source, dict.Keyword)
+		h.cache.RemoveKeyword(event.Context(), source, keyword)
+		slog.DebugContext(event.Context(), "Keyword not found", "keyword", keyword, "found", dict.Keyword)
 		return
 	}
-	h.redis.AddKeyword(source, keyword, dict.Description)
+	h.cache.AddKeyword(event.Context(), source, keyword, dict.Description)
 	h.addEntry(event, source, keyword, dict.Description)
 }
 
-func (h *Handler) addEntry(event *linebot.Event, source, keyword, desc string) {
-	h.reply(event, keyword+", "+desc+" lagi?")
-	err := h.mysql.CreateEntry(&model.Entry{
+func (h *Handler) addEntry(event *chat.Event, source, keyword, desc string) {
+	messages := []string{keyword + ", " + desc + " lagi?"}
+	entry := &model.Entry{
 		Keyword: keyword,
 		Source:  source,
-	})
-	if err != nil {
-		log.Printf("Cannot add counter %s in %s\n", keyword, source)
+		EventID: event.EventID,
+	}
+	err := h.store.CreateEntry(event.Context(), entry)
+	if err == service.ErrDuplicate {
+		slog.InfoContext(event.Context(), "Already counted", "keyword", keyword)
+		return
+	} else if err != nil {
+		// not replied, so a redelivery counts it again
+		slog.ErrorContext(event.Context(), "Cannot count keyword", "keyword", keyword, logging.Err(err))
 		return
 	}
+	committed(event.Context())
+	metrics.KeywordEntries.WithLabelValues(platformName(event)).Inc()
+	messages = append(messages, h.unlockBadges(event.Context(), entry, desc)...)
+	h.reply(event, messages...)
+}
+
+func (h *Handler) getProfileName(ctx context.Context, userId string) string {
+	name, _ := h.getProfile(ctx, userId)
+	return name
 }
 
-func (h *Handler) getProfileName(userId string) string {
+// getProfile returns the display name and picture URL of a user.
+func (h *Handler) getProfile(ctx context.Context, userId string) (string, string) {
 	// look up from cache
-	name, _ := h.redis.GetDisplayName(userId)
+	name, _ := h.cache.GetDisplayName(ctx, userId)
 	if name != "" {
-		return name
+		picture, _ := h.cache.GetPictureURL(ctx, userId)
+		return name, picture
 	}
 
-	// look up from database
-	profile, err := h.bot.GetProfile(userId).Do()
+	// only LINE has a profile API, other platforms fill the cache themselves
+	if chat.PlatformOf(userId) != chat.PlatformLINE {
+		return "", ""
+	}
+	profile, err := h.bot.GetProfile(userId).WithContext(ctx).Do()
 	if err != nil {
-		return ""
+		return "", ""
 	}
 	// update cache
-	h.redis.SetDisplayName(userId, profile.DisplayName)
-	return profile.DisplayName
+	h.cache.SetDisplayName(ctx, userId, profile.DisplayName)
+	h.cache.SetPictureURL(ctx, userId, profile.PictureURL)
+	return profile.DisplayName, profile.PictureURL
+}
+
+func (h *Handler) location(ctx context.Context, source string) *time.Location {
+	group, err := h.store.GetGroup(ctx, source)
+	if err != nil {
+		slog.ErrorContext(ctx, "Cannot fetch group", logging.Err(err))
+	}
+	return util.Location(group.Timezone)
 }
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/luqmanarifin/kentang/queue"
+)
+
+// healthTimeout bounds each dependency check, so a hanging connection fails
+// the check instead of the probe.
+const healthTimeout = 2 * time.Second
+
+// pinger is a dependency that can tell whether it's reachable, like MySQL and
+// Redis. Dependencies that can't, like the in-memory ones, are always up.
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+type check struct {
+	Status    string  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type health struct {
+	Status string           `json:"status"`
+	Checks map[string]check `json:"checks"`
+	Queue  queue.Stats      `json:"queue"`
+}
+
+// checkHealth pings the store and the cache at the same time. The store is
+// critical: without it nothing can be counted. Without the cache the bot
+// still works, only slower and without skipping redelivered events.
+func (h *Handler) checkHealth(ctx context.Context) health {
+	dependencies := []struct {
+		name     string
+		critical bool
+		dep      interface{}
+	}{
+		{"store", true, h.store},
+		{"cache", false, h.cache},
+	}
+
+	result := health{Status: "ok", Checks: make(map[string]check), Queue: h.queue.Stats()}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, d := range dependencies {
+		d := d
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c := check{Status: "ok", Critical: d.critical}
+			if p, ok := d.dep.(pinger); ok {
+				ctx, cancel := context.WithTimeout(ctx, healthTimeout)
+				defer cancel()
+				start := time.Now()
+				err := p.Ping(ctx)
+				c.LatencyMS = float64(time.Since(start).Microseconds()) / 1000
+				if err != nil {
+					c.Status, c.Error = "down", err.Error()
+				}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			result.Checks[d.name] = c
+		}()
+	}
+	wg.Wait()
+
+	for _, c := range result.Checks {
+		if c.Status == "ok" {
+			continue
+		}
+		if c.Critical {
+			result.Status = "unavailable"
+			break
+		}
+		result.Status = "degraded"
+	}
+	return result
+}
+
+// Healthz - liveness check. It answers 503 when a critical dependency is
+// down, and reports the others.
+func (h *Handler) Healthz(w http.ResponseWriter, r *http.Request) {
+	result := h.checkHealth(r.Context())
+	writeHealth(w, result, result.Status != "unavailable")
+}
+
+// Readyz - readiness check. Besides the dependencies it fails once the
+// webhook queue is draining, so no new events are sent while shutting down.
+func (h *Handler) Readyz(w http.ResponseWriter, r *http.Request) {
+	result := h.checkHealth(r.Context())
+	if h.queue.Closed() {
+		result.Status = "draining"
+	}
+	writeHealth(w, result, result.Status == "ok" || result.Status == "degraded")
+}
+
+func writeHealth(w http.ResponseWriter, result health, ok bool) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(result)
+}
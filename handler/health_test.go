@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/luqmanarifin/kentang/service"
+)
+
+type downStore struct{ *service.Memory }
+
+func (downStore) Ping(ctx context.Context) error { return errors.New("connection refused") }
+
+type downCache struct{ *service.MemoryCache }
+
+func (downCache) Ping(ctx context.Context) error { return errors.New("i/o timeout") }
+
+func (downCache) GetKeyword(ctx context.Context, source, keyword string) (string, error) {
+	return "", errors.New("i/o timeout")
+}
+
+func TestHealth(t *testing.T) {
+	tests := []struct {
+		name   string
+		h      *Handler
+		status string
+		code   int
+	}{
+		{"up", New(service.NewMemory(), service.NewMemoryCache()), "ok", 200},
+		{"cache down", New(service.NewMemory(), downCache{service.NewMemoryCache()}), "degraded", 200},
+		{"store down", New(downStore{service.NewMemory()}, service.NewMemoryCache()), "unavailable", 503},
+	}
+	for _, test := range tests {
+		for path, serve := range map[string]http.HandlerFunc{"/healthz": test.h.Healthz, "/readyz": test.h.Readyz} {
+			w := httptest.NewRecorder()
+			serve(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+			var got health
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatalf("%s %s: %v", test.name, path, err)
+			}
+			if w.Code != test.code || got.Status != test.status {
+				t.Errorf("%s %s: got %d %s, want %d %s", test.name, path, w.Code, got.Status, test.code, test.status)
+			}
+			if len(got.Checks) != 2 {
+				t.Errorf("%s %s: got checks %v", test.name, path, got.Checks)
+			}
+		}
+	}
+}
+
+func TestKeywordWhileCacheDown(t *testing.T) {
+	store := service.NewMemory()
+	_, api, send := newLineHandler(t, store, downCache{service.NewMemoryCache()})
+
+	send(api.TextEvent("G1", "U1", "add telat terlambat"))
+	if got := send(api.TextEvent("G1", "U1", "halo")); got != "" {
+		t.Errorf("replied %q to a message that isn't a keyword", got)
+	}
+	if got := send(api.TextEvent("G1", "U1", "telat")); !strings.HasPrefix(got, "telat, terlambat lagi?") {
+		t.Errorf("got %q, want the keyword counted from the store", got)
+	}
+	if entries, _ := store.GetAllEntries(context.Background(), "G1"); len(entries) != 1 {
+		t.Errorf("got %d entries, want 1", len(entries))
+	}
+}
+
+func TestReadyzWhileDraining(t *testing.T) {
+	h := New(service.NewMemory(), service.NewMemoryCache())
+	h.Drain(context.Background())
+
+	w := httptest.NewRecorder()
+	h.Readyz(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if w.Code != 503 {
+		t.Errorf("got status %d while draining, want 503", w.Code)
+	}
+	w = httptest.NewRecorder()
+	h.Healthz(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if w.Code != 200 {
+		t.Errorf("got liveness %d while draining, want 200", w.Code)
+	}
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/line/line-bot-sdk-go/v7/linebot"
+	"github.com/luqmanarifin/kentang/chat"
+	"github.com/luqmanarifin/kentang/logging"
+	"github.com/luqmanarifin/kentang/util"
+)
+
+const chartTTL = 24 * time.Hour
+
+// Chart serves a generated chart, e.g. /chart/<id>.png?exp=<unix>&sig=<sig>.
+func (h *Handler) Chart(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/chart/"), ".png")
+	exp := r.URL.Query().Get("exp")
+	if !util.Verify(h.secret, id+":"+exp, r.URL.Query().Get("sig")) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	expiry, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || time.Now().Unix() > expiry {
+		w.WriteHeader(http.StatusGone)
+		return
+	}
+
+	png, err := h.cache.GetChart(r.Context(), id)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "public, max-age="+strconv.FormatInt(expiry-time.Now().Unix(), 10))
+	w.Write(png)
+}
+
+// chartsEnabled tells whether images can be served, which needs a public
+// https address.
+func (h *Handler) chartsEnabled() bool {
+	return strings.HasPrefix(h.baseURL, "https://")
+}
+
+// replyChart stores a rendered chart and replies with an image pointing at it.
+func (h *Handler) replyChart(event *chat.Event, png []byte) error {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	id := hex.EncodeToString(b)
+	if err := h.cache.SetChart(event.Context(), id, png, chartTTL); err != nil {
+		slog.ErrorContext(event.Context(), "Cannot cache chart", "chart", id, logging.Err(err))
+		return err
+	}
+
+	exp := strconv.FormatInt(time.Now().Add(chartTTL).Unix(), 10)
+	url := h.baseURL + "/chart/" + id + ".png?exp=" + exp + "&sig=" + util.Sign(h.secret, id+":"+exp)
+	return h.replyMessages(event, linebot.NewImageMessage(url, url))
+}
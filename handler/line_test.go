@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/luqmanarifin/kentang/chat"
+	"github.com/luqmanarifin/kentang/linetest"
+	"github.com/luqmanarifin/kentang/service"
+)
+
+// newLineHandler makes a handler on store and cache, talking to a fake LINE
+// API that is closed when the test ends. send delivers an event through the
+// webhook, waits until it's handled and returns the text of the replies to it.
+func newLineHandler(t *testing.T, store service.Store, cache service.Cache) (*Handler, *linetest.Server, func(linetest.Event) string) {
+	t.Helper()
+	api := linetest.NewServer()
+	t.Cleanup(api.Close)
+	h := New(store, cache, &chat.Line{Client: api.Client()})
+
+	send := func(event linetest.Event) string {
+		t.Helper()
+		api.Reset()
+		h.Callback(httptest.NewRecorder(), api.Webhook(event))
+		h.queue.Wait()
+		var texts []string
+		for _, reply := range api.Replies() {
+			texts = append(texts, reply.Texts()...)
+		}
+		return strings.Join(texts, "\n")
+	}
+	return h, api, send
+}
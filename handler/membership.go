@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/line/line-bot-sdk-go/v7/linebot"
+	"github.com/luqmanarifin/kentang/chat"
+	"github.com/luqmanarifin/kentang/logging"
+	"github.com/luqmanarifin/kentang/model"
+	"github.com/luqmanarifin/kentang/scheduler"
+)
+
+// purgeGrace is how long a group's data is kept after the bot leaves, so
+// re-inviting it by mistake doesn't lose anything.
+const purgeGrace = 30 * 24 * time.Hour
+
+// handleJoin greets a group or user, and brings back their keywords when the
+// bot was there before.
+func (h *Handler) handleJoin(event *chat.Event) {
+	source := event.Source
+	group, err := h.store.GetGroup(event.Context(), source)
+	if err != nil {
+		slog.ErrorContext(event.Context(), "Cannot fetch group", logging.Err(err))
+		h.handleFollow(event)
+		return
+	}
+	if group.LeftAt == nil {
+		h.handleFollow(event)
+		return
+	}
+
+	group.LeftAt = nil
+	if err := h.store.SaveGroup(event.Context(), &group); err != nil {
+		slog.ErrorContext(event.Context(), "Cannot reactivate group", logging.Err(err))
+	} else {
+		committed(event.Context())
+	}
+	message := "Welcome back! Your keywords are still here."
+	if keywords := h.keywordList(event.Context(), source); keywords != "" {
+		message = message + "\n" + keywords
+	}
+	h.reply(event, message)
+}
+
+// handleLeave marks a source inactive. Its data is purged by the purge job
+// once purgeGrace has passed.
+func (h *Handler) handleLeave(event *chat.Event) {
+	source := event.Source
+	group, err := h.store.GetGroup(event.Context(), source)
+	if err != nil {
+		slog.ErrorContext(event.Context(), "Cannot fetch group", logging.Err(err))
+		return
+	}
+	now := time.Now()
+	group.LeftAt = &now
+	if err := h.store.SaveGroup(event.Context(), &group); err != nil {
+		slog.ErrorContext(event.Context(), "Cannot mark group as left", logging.Err(err))
+	} else {
+		committed(event.Context())
+	}
+	if err := h.cache.RemoveAllKeyword(event.Context(), source); err != nil {
+		slog.ErrorContext(event.Context(), "Cannot clear cache", logging.Err(err))
+	}
+}
+
+func (h *Handler) handleMemberJoined(event *chat.Event, joined *linebot.Members) {
+	if joined == nil {
+		return
+	}
+	var names []string
+	for _, member := range joined.Members {
+		if name := h.getProfileName(event.Context(), member.UserID); name != "" {
+			names = append(names, name)
+		}
+	}
+	message := "Welcome!"
+	if len(names) > 0 {
+		message = "Welcome, " + strings.Join(names, ", ") + "!"
+	}
+
+	source := event.Source
+	if keywords := h.keywordList(event.Context(), source); keywords != "" {
+		message = message + "\n" + keywords
+	} else {
+		message = message + "\nNo keyword yet, try add [keyword] [desc]"
+	}
+	h.reply(event, message)
+}
+
+// handleMemberLeft forgets the cached profiles of members who left.
+func (h *Handler) handleMemberLeft(ctx context.Context, left *linebot.Members) {
+	if left == nil {
+		return
+	}
+	for _, member := range left.Members {
+		h.cache.RemoveProfile(ctx, member.UserID)
+	}
+}
+
+// keywordList lists the keywords of source in one line, or returns an empty
+// string when it has none.
+func (h *Handler) keywordList(ctx context.Context, source string) string {
+	dicts, err := h.store.GetAllDictionaries(ctx, source)
+	if err != nil {
+		slog.ErrorContext(ctx, "Cannot fetch keywords", logging.Err(err))
+		return ""
+	}
+	var keywords []string
+	for _, dict := range dicts {
+		if dict.Target == "" {
+			keywords = append(keywords, dict.Keyword)
+		}
+	}
+	if len(keywords) == 0 {
+		return ""
+	}
+	return "Keywords: " + strings.Join(keywords, ", ")
+}
+
+func (h *Handler) purgeJob() scheduler.Job {
+	return scheduler.Job{
+		Name:   "purge-left",
+		Period: scheduler.Daily,
+		Targets: func(ctx context.Context) ([]model.Group, error) {
+			return h.store.GetGroupsLeftBefore(ctx, time.Now().Add(-purgeGrace))
+		},
+		Run: func(ctx context.Context, group model.Group, due time.Time) error {
+			return h.purge(ctx, group.Source)
+		},
+	}
+}
+
+// purge removes everything kept for source.
+func (h *Handler) purge(ctx context.Context, source string) error {
+	slog.InfoContext(ctx, "Purging group")
+	if err := h.store.RemoveDictionaryBySource(ctx, source); err != nil {
+		return err
+	}
+	if err := h.store.RemoveEntryBySource(ctx, source); err != nil {
+		return err
+	}
+	if err := h.store.RemoveBadgeBySource(ctx, source); err != nil {
+		return err
+	}
+	if err := h.store.RemoveBindingBySource(ctx, source); err != nil {
+		return err
+	}
+	if err := h.store.RemoveSeasonBySource(ctx, source); err != nil {
+		return err
+	}
+	// the group row goes with the rest even when the cache fails, or a
+	// re-invited bot would welcome the group back to nothing
+	if err := h.cache.RemoveAllKeyword(ctx, source); err != nil {
+		slog.ErrorContext(ctx, "Cannot clear cache", logging.Err(err))
+	}
+	return h.store.RemoveGroup(ctx, source)
+}
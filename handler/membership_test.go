@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/luqmanarifin/kentang/service"
+)
+
+// failingCache can't clear keywords, as Redis couldn't when a group had none
+// cached.
+type failingCache struct{ *service.MemoryCache }
+
+func (failingCache) RemoveAllKeyword(ctx context.Context, source string) error {
+	return errors.New("ERR wrong number of arguments for 'del' command")
+}
+
+func TestLeaveRejoinAndPurge(t *testing.T) {
+	store := service.NewMemory()
+	h, api, send := newLineHandler(t, store, failingCache{service.NewMemoryCache()})
+	ctx := context.Background()
+
+	send(api.TextEvent("G1", "U1", "add telat terlambat"))
+	send(api.LeaveEvent("G1"))
+	if group, _ := store.GetGroup(ctx, "G1"); group.LeftAt == nil {
+		t.Fatal("group isn't marked as left")
+	}
+	if got := send(api.JoinEvent("G1")); !strings.Contains(got, "Welcome back") || !strings.Contains(got, "telat") {
+		t.Errorf("rejoined within the grace period: got %q", got)
+	}
+
+	send(api.LeaveEvent("G1"))
+	group, _ := store.GetGroup(ctx, "G1")
+	left := time.Now().Add(-purgeGrace - time.Hour)
+	group.LeftAt = &left
+	store.SaveGroup(ctx, &group)
+	job := h.purgeJob()
+	targets, err := job.Targets(ctx)
+	if err != nil || len(targets) != 1 {
+		t.Fatalf("got purge targets %v, %v", targets, err)
+	}
+	if err := job.Run(ctx, targets[0], time.Now()); err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	if group, _ := store.GetGroup(ctx, "G1"); group.LeftAt != nil {
+		t.Error("group is still marked as left after the purge")
+	}
+	if dicts, _ := store.GetAllDictionaries(ctx, "G1"); len(dicts) != 0 {
+		t.Errorf("%d keywords left after the purge", len(dicts))
+	}
+	if got := send(api.JoinEvent("G1")); strings.Contains(got, "Welcome back") {
+		t.Errorf("rejoined after the purge: got %q", got)
+	}
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/line/line-bot-sdk-go/v7/linebot"
+	"github.com/luqmanarifin/kentang/chat"
+	"github.com/luqmanarifin/kentang/logging"
+	"github.com/luqmanarifin/kentang/metrics"
+	"github.com/luqmanarifin/kentang/model"
+	"github.com/luqmanarifin/kentang/service"
+	"github.com/luqmanarifin/kentang/util"
+)
+
+// handleMention handles messages mentioning group members, which count
+// keywords attached to them. It returns false when the message isn't about a
+// mentioned member, along with the text to handle instead, which has the
+// mentions cut out.
+func (h *Handler) handleMention(event *chat.Event, message *linebot.TextMessage) (string, bool) {
+	rest, users := util.SplitMentions(message.Text, message.Mention)
+	if len(users) == 0 {
+		return message.Text, false
+	}
+	// the mention shows the name the group sees, keep it for highscores
+	for _, user := range users {
+		if name, _ := h.cache.GetDisplayName(event.Context(), user.UserID); name == "" {
+			h.cache.SetDisplayName(event.Context(), user.UserID, user.Name)
+		}
+	}
+
+	tokens := strings.Fields(rest)
+	switch {
+	case len(tokens) == 3 && strings.ToLower(tokens[0]) == "add" && len(users) == 1:
+		h.handleAddPerson(event, users[0], tokens[1], tokens[2])
+	case len(tokens) == 2 && strings.ToLower(tokens[0]) == "remove" && len(users) == 1:
+		h.handleRemovePerson(event, users[0], tokens[1])
+	case len(tokens) == 1 && h.handlePersonKeyword(event, users, tokens[0]):
+	default:
+		return strings.Join(tokens, " "), false
+	}
+	return "", true
+}
+
+func (h *Handler) handleAddPerson(event *chat.Event, user util.MentionedUser, keyword, desc string) {
+	source := event.Source
+	dict, err := h.store.GetDictionaryByTarget(event.Context(), source, keyword, user.UserID)
+	if err == nil && dict.Keyword == keyword {
+		h.reply(event, keyword+" is already here before for "+user.Name+".")
+		return
+	}
+	err = h.store.CreateDictionary(event.Context(), &model.Dictionary{
+		Source:      source,
+		Keyword:     keyword,
+		Description: desc,
+		Creator:     event.UserID,
+		Target:      user.UserID,
+	})
+	if err != nil {
+		slog.ErrorContext(event.Context(), "Cannot add person keyword", "keyword", keyword, "target", user.UserID, logging.Err(err))
+		return
+	}
+	committed(event.Context())
+	h.reply(event, keyword+" has been added for "+user.Name)
+}
+
+func (h *Handler) handleRemovePerson(event *chat.Event, user util.MentionedUser, keyword string) {
+	source := event.Source
+	dict, err := h.store.GetDictionaryByTarget(event.Context(), source, keyword, user.UserID)
+	if err != nil || dict.Keyword != keyword {
+		h.reply(event, "Keyword "+keyword+" is not exists for "+user.Name)
+		return
+	}
+	if event.UserID != dict.Creator {
+		h.reply(event, "Only the creator can remove it")
+		return
+	}
+	err = h.removeKeyword(event.Context(), dict)
+	if err != nil {
+		slog.ErrorContext(event.Context(), "Cannot remove person keyword", "keyword", keyword, "target", user.UserID, logging.Err(err))
+		return
+	}
+	h.reply(event, "Keyword "+keyword+" removed for "+user.Name)
+}
+
+// handlePersonKeyword counts keyword against every mentioned user it was
+// added for. It returns false when it wasn't added for any of them.
+func (h *Handler) handlePersonKeyword(event *chat.Event, users []util.MentionedUser, keyword string) bool {
+	source := event.Source
+	var messages []string
+	found := false
+	counted := make(map[string]bool)
+	for _, user := range users {
+		if counted[user.UserID] {
+			continue
+		}
+		dict, err := h.store.GetDictionaryByTarget(event.Context(), source, keyword, user.UserID)
+		if err != nil || dict.Keyword != keyword {
+			continue
+		}
+		found = true
+		err = h.store.CreateEntry(event.Context(), &model.Entry{
+			Source:  source,
+			Keyword: keyword,
+			Target:  user.UserID,
+			EventID: targetEventID(event.EventID, user.UserID),
+		})
+		if err == service.ErrDuplicate {
+			continue
+		} else if err != nil {
+			slog.ErrorContext(event.Context(), "Cannot count person keyword", "keyword", keyword, "target", user.UserID, logging.Err(err))
+			continue
+		}
+		committed(event.Context())
+		metrics.KeywordEntries.WithLabelValues(platformName(event)).Inc()
+		counted[user.UserID] = true
+		messages = append(messages, user.Name+" "+dict.Description+" lagi?")
+	}
+	if len(messages) > 0 {
+		h.reply(event, strings.Join(messages, "\n"))
+	}
+	return found
+}
+
+// targetEventID tells apart the entries one message counts for several
+// people.
+func targetEventID(eventID, target string) string {
+	if eventID == "" {
+		return ""
+	}
+	return eventID + ":" + target
+}
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/luqmanarifin/kentang/service"
+)
+
+func TestMentionsFallThroughToCommands(t *testing.T) {
+	store := service.NewMemory()
+	_, api, send := newLineHandler(t, store, service.NewMemoryCache())
+
+	send(api.TextEvent("G1", "U1", "add telat terlambat"))
+	if got := send(api.MentionEvent("G1", "U1", "U2", "Niki", "add bolos membolos")); got != "bolos has been added for Niki" {
+		t.Errorf("adding a person keyword: got %q", got)
+	}
+	if got := send(api.MentionEvent("G1", "U1", "U2", "Niki", "bolos")); got != "Niki membolos lagi?" {
+		t.Errorf("counting a person keyword: got %q", got)
+	}
+	if got := send(api.MentionEvent("G1", "U1", "U2", "Niki", "telat")); !strings.HasPrefix(got, "telat, terlambat lagi?") {
+		t.Errorf("a keyword not added for the member: got %q", got)
+	}
+	if got := send(api.MentionEvent("G1", "U1", "U2", "Niki", "highscore")); !strings.Contains(got, "telat") {
+		t.Errorf("a command mentioning a member: got %q", got)
+	}
+	if entries, _ := store.GetEntriesByKeyword(context.Background(), "G1", "telat"); len(entries) != 1 {
+		t.Errorf("got %d telat entries, want 1", len(entries))
+	}
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"time"
+	"unicode/utf8"
+
+	"github.com/line/line-bot-sdk-go/v7/linebot"
+	"github.com/luqmanarifin/kentang/action"
+	"github.com/luqmanarifin/kentang/chat"
+	"github.com/luqmanarifin/kentang/logging"
+)
+
+const (
+	actionHighscore = "highscore"
+	actionStat      = "stat"
+	actionRemove    = "remove"
+	actionReset     = "reset"
+	actionCancel    = "cancel"
+
+	actionMaxAge  = 7 * 24 * time.Hour
+	confirmMaxAge = 10 * time.Minute
+
+	maxQuickReplies    = 13
+	maxQuickReplyLabel = 20
+)
+
+const (
+	highscoreWeek  = "week"
+	highscoreMonth = "month"
+	highscoreAll   = "all"
+)
+
+var highscoreTitles = map[string]string{
+	highscoreWeek:  "Highscore this week",
+	highscoreMonth: "Highscore",
+	highscoreAll:   "All time highscore",
+}
+
+func (h *Handler) handlePostback(event *chat.Event, postback *linebot.Postback) {
+	source := event.Source
+	a, err := action.Decode(h.secret, source, postback.Data, actionMaxAge)
+	if err == action.ErrExpired {
+		h.reply(event, "This button has expired.")
+		return
+	}
+	if err != nil {
+		slog.WarnContext(event.Context(), "Invalid postback", logging.Err(err))
+		return
+	}
+
+	switch a.Name {
+	case actionHighscore:
+		h.handleHighscore(event, append([]string{"highscore"}, a.Args...))
+	case actionStat:
+		h.handleStat(event, append([]string{"stat"}, a.Args...))
+	case actionRemove:
+		h.handleRemove(event, append([]string{"remove"}, a.Args...))
+	case actionReset:
+		if time.Since(a.IssuedAt) > confirmMaxAge || len(a.Args) != 1 {
+			h.reply(event, "This confirmation has expired, send reset again.")
+			return
+		}
+		// the confirmation only resets once, tapping it again must not wipe
+		// what was counted since
+		first, err := h.cache.UseNonce(event.Context(), a.Args[0], confirmMaxAge)
+		if err != nil {
+			slog.ErrorContext(event.Context(), "Cannot use reset nonce", logging.Err(err))
+			return
+		}
+		if !first {
+			h.reply(event, "This confirmation was already used, send reset again.")
+			return
+		}
+		h.reset(event)
+	case actionCancel:
+		h.reply(event, "Cancelled.")
+	}
+}
+
+// newNonce returns a random value to make an action single-use.
+func newNonce() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// postbackButton makes a quick reply button sending a as a postback. The
+// user appears to say displayText. It returns nil when a doesn't fit.
+func (h *Handler) postbackButton(event *chat.Event, label, displayText string, a action.Action) *linebot.QuickReplyButton {
+	source := event.Source
+	data, err := action.Encode(h.secret, source, a)
+	if err != nil {
+		slog.ErrorContext(event.Context(), "Cannot encode action", "action", a.Name, logging.Err(err))
+		return nil
+	}
+	if utf8.RuneCountInString(label) > maxQuickReplyLabel {
+		label = string([]rune(label)[:maxQuickReplyLabel-1]) + "…"
+	}
+	return linebot.NewQuickReplyButton("", linebot.NewPostbackAction(label, data, "", displayText, "", ""))
+}
+
+// quickReplies collects the buttons that could be made, up to what LINE
+// allows.
+func quickReplies(buttons ...*linebot.QuickReplyButton) *linebot.QuickReplyItems {
+	var items []*linebot.QuickReplyButton
+	for _, button := range buttons {
+		if button != nil && len(items) < maxQuickReplies {
+			items = append(items, button)
+		}
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	return linebot.NewQuickReplyItems(items...)
+}
+
+func (h *Handler) highscoreQuickReplies(event *chat.Event, current, topKeyword string) *linebot.QuickReplyItems {
+	var buttons []*linebot.QuickReplyButton
+	for _, period := range []string{highscoreWeek, highscoreMonth, highscoreAll} {
+		if period == current {
+			continue
+		}
+		label := period
+		if period == highscoreAll {
+			label = "all time"
+		}
+		buttons = append(buttons, h.postbackButton(event, label, "highscore "+period, action.New(actionHighscore, period)))
+	}
+	if topKeyword != "" {
+		buttons = append(buttons, h.postbackButton(event, "stat "+topKeyword, "stat "+topKeyword, action.New(actionStat, topKeyword)))
+	}
+	return quickReplies(buttons...)
+}
+
+// pickKeywordToRemove offers the keywords the user created as buttons.
+func (h *Handler) pickKeywordToRemove(event *chat.Event) {
+	source := event.Source
+	dicts, err := h.store.GetAllDictionaries(event.Context(), source)
+	if err != nil {
+		slog.ErrorContext(event.Context(), "Cannot fetch keywords", logging.Err(err))
+		return
+	}
+	var buttons []*linebot.QuickReplyButton
+	for _, dict := range dicts {
+		if dict.Creator != event.UserID {
+			continue
+		}
+		buttons = append(buttons, h.postbackButton(event, dict.Keyword, "remove "+dict.Keyword, action.New(actionRemove, dict.Keyword)))
+	}
+	quick := quickReplies(buttons...)
+	if quick == nil {
+		h.reply(event, "You haven't added any keyword.")
+		return
+	}
+	if !event.Is(chat.PlatformLINE) {
+		h.reply(event, "Send remove [keyword] to remove one of yours.")
+		return
+	}
+	h.replyQuick(event, quick, "Which keyword to remove?")
+}
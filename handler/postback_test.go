@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/luqmanarifin/kentang/service"
+)
+
+func TestResetConfirmationIsSingleUse(t *testing.T) {
+	store := service.NewMemory()
+	_, api, send := newLineHandler(t, store, service.NewMemoryCache())
+	ctx := context.Background()
+
+	send(api.TextEvent("G1", "U1", "add telat terlambat"))
+	send(api.TextEvent("G1", "U1", "reset"))
+	replies := api.Replies()
+	if len(replies) != 1 || len(replies[0].Messages) != 1 {
+		t.Fatalf("got replies %+v", replies)
+	}
+	data := replies[0].Messages[0].Postbacks["Yes, reset"]
+	if data == "" {
+		t.Fatalf("no confirmation button in %+v", replies[0].Messages[0])
+	}
+	if got := send(api.PostbackEvent("G1", "U1", data)); got != "All cleared up." {
+		t.Fatalf("confirming: got %q", got)
+	}
+
+	send(api.TextEvent("G1", "U1", "add telat terlambat"))
+	if got := send(api.PostbackEvent("G1", "U1", data)); !strings.Contains(got, "already used") {
+		t.Errorf("confirming again: got %q", got)
+	}
+	if dicts, _ := store.GetAllDictionaries(ctx, "G1"); len(dicts) != 1 {
+		t.Errorf("the second confirmation reset the group again, %d keywords left", len(dicts))
+	}
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/luqmanarifin/kentang/logging"
+	"github.com/luqmanarifin/kentang/queue"
+)
+
+const (
+	queueWorkers = 8
+	// queueSize is how many events each worker holds before webhooks are
+	// turned away.
+	queueSize = 256
+	// eventTimeout bounds the handling of one event, so a slow database
+	// holds up its worker for that long at most. LINE reply tokens don't last
+	// much longer anyway.
+	eventTimeout = 30 * time.Second
+)
+
+// enqueue runs handle after the earlier events of source. It returns false
+// when the event was dropped.
+func (h *Handler) enqueue(source string, handle func()) bool {
+	err := h.queue.Submit(source, handle)
+	if err != nil {
+		stats := h.queue.Stats()
+		slog.Warn("Dropped event", "source", source, logging.Err(err), "depth", stats.Depth, "rejected", stats.Rejected)
+		return false
+	}
+	return true
+}
+
+// QueueStats tells how far behind the webhook workers are.
+func (h *Handler) QueueStats() queue.Stats {
+	return h.queue.Stats()
+}
+
+// Drain stops taking webhook events and waits for the queued ones to be
+// handled. When ctx is done first, the events still running are cancelled.
+func (h *Handler) Drain(ctx context.Context) error {
+	err := h.queue.Drain(ctx)
+	if err != nil {
+		h.cancel()
+	}
+	return err
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/luqmanarifin/kentang/chat"
+	"github.com/luqmanarifin/kentang/logging"
+	"github.com/luqmanarifin/kentang/model"
+	"github.com/luqmanarifin/kentang/scheduler"
+)
+
+var recapTitles = map[scheduler.Period]string{
+	scheduler.Daily:   "Yesterday's highscore:",
+	scheduler.Weekly:  "Last week's highscore:",
+	scheduler.Monthly: "Last month's highscore:",
+}
+
+// Scheduler returns a scheduler pushing the recap of each period to the
+// groups subscribed to it, rolling over seasons monthly and purging the
+// groups the bot left.
+func (h *Handler) Scheduler() *scheduler.Scheduler {
+	var jobs []scheduler.Job
+	for _, period := range []scheduler.Period{scheduler.Daily, scheduler.Weekly, scheduler.Monthly} {
+		jobs = append(jobs, h.recapJob(period))
+	}
+	jobs = append(jobs, h.seasonJob(), h.purgeJob())
+	return scheduler.New(h.store, h.cache, jobs...)
+}
+
+func (h *Handler) recapJob(period scheduler.Period) scheduler.Job {
+	return scheduler.Job{
+		Name:   "recap-" + string(period),
+		Period: period,
+		Targets: func(ctx context.Context) ([]model.Group, error) {
+			return h.store.GetGroupsByRecap(ctx, string(period))
+		},
+		Run: func(ctx context.Context, group model.Group, due time.Time) error {
+			entries, err := h.store.GetEntriesBetween(ctx, group.Source, period.Prev(due), due)
+			if err != nil {
+				return err
+			}
+			if len(entries) == 0 {
+				return nil
+			}
+			message, err := h.highscoreMessage(ctx, group.Source, recapTitles[period], entries)
+			if err != nil {
+				return err
+			}
+			return h.push(ctx, group.Source, message)
+		},
+	}
+}
+
+func (h *Handler) handleTimezone(event *chat.Event, tokens []string) {
+	if len(tokens) > 2 {
+		return
+	}
+	source := event.Source
+	group, err := h.store.GetGroup(event.Context(), source)
+	if err != nil {
+		slog.ErrorContext(event.Context(), "Cannot fetch group", logging.Err(err))
+		return
+	}
+	if len(tokens) == 1 {
+		h.reply(event, "Timezone: "+group.Timezone)
+		return
+	}
+	if _, err := time.LoadLocation(tokens[1]); err != nil || tokens[1] == "" || tokens[1] == "Local" {
+		h.reply(event, "Unknown timezone "+tokens[1])
+		return
+	}
+	group.Timezone = tokens[1]
+	if err := h.store.SaveGroup(event.Context(), &group); err != nil {
+		slog.ErrorContext(event.Context(), "Cannot save timezone", logging.Err(err))
+		return
+	}
+	committed(event.Context())
+	h.reply(event, "Timezone set to "+group.Timezone)
+}
+
+func (h *Handler) handleRecap(event *chat.Event, tokens []string) {
+	if len(tokens) > 2 {
+		return
+	}
+	source := event.Source
+	group, err := h.store.GetGroup(event.Context(), source)
+	if err != nil {
+		slog.ErrorContext(event.Context(), "Cannot fetch group", logging.Err(err))
+		return
+	}
+	if len(tokens) == 1 {
+		if group.Recap == "" {
+			h.reply(event, "Recap is off")
+		} else {
+			h.reply(event, "Recap: "+group.Recap)
+		}
+		return
+	}
+
+	arg := strings.ToLower(tokens[1])
+	if arg == "off" {
+		group.Recap = ""
+	} else if period, err := scheduler.ParsePeriod(arg); err == nil {
+		if group.Recap != string(period) {
+			now := time.Now()
+			group.SubscribedAt = &now
+		}
+		group.Recap = string(period)
+	} else {
+		h.reply(event, "Recap can be daily, weekly, monthly or off")
+		return
+	}
+	if err := h.store.SaveGroup(event.Context(), &group); err != nil {
+		slog.ErrorContext(event.Context(), "Cannot save recap", logging.Err(err))
+		return
+	}
+	committed(event.Context())
+	if group.Recap == "" {
+		h.reply(event, "Recap turned off")
+	} else {
+		h.reply(event, "Recap will be sent "+group.Recap+" at midnight "+group.Timezone)
+	}
+}
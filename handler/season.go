@@ -0,0 +1,270 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/luqmanarifin/kentang/chat"
+	"github.com/luqmanarifin/kentang/logging"
+	"github.com/luqmanarifin/kentang/model"
+	"github.com/luqmanarifin/kentang/scheduler"
+	"github.com/luqmanarifin/kentang/util"
+)
+
+var errEmptySeason = errors.New("no entries this season")
+
+func (h *Handler) seasonJob() scheduler.Job {
+	return scheduler.Job{
+		Name:    "season-rollover",
+		Period:  scheduler.Monthly,
+		Targets: h.store.GetAutoSeasonGroups,
+		Run: func(ctx context.Context, group model.Group, due time.Time) error {
+			season, standings, err := h.endSeason(ctx, group, due)
+			if err == errEmptySeason {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			return h.push(ctx, group.Source, seasonEndedMessage(season, standings))
+		},
+	}
+}
+
+// endSeason archives the standings of the entries since the previous season
+// ended up to end.
+func (h *Handler) endSeason(ctx context.Context, group model.Group, end time.Time) (model.Season, []model.Standing, error) {
+	last, err := h.store.GetLastSeason(ctx, group.Source)
+	if err != nil {
+		return model.Season{}, nil, err
+	}
+	entries, err := h.store.GetEntriesBetween(ctx, group.Source, last.EndedAt, end)
+	if err != nil {
+		return model.Season{}, nil, err
+	}
+	if len(entries) == 0 {
+		return model.Season{}, nil, errEmptySeason
+	}
+
+	standings := h.standings(ctx, group.Source, entries)
+
+	name, err := h.seasonName(ctx, group, end)
+	if err != nil {
+		return model.Season{}, nil, err
+	}
+	season := model.Season{
+		Source:    group.Source,
+		Name:      name,
+		StartedAt: last.EndedAt,
+		EndedAt:   end,
+	}
+	if last.ID == 0 {
+		season.StartedAt = entries[0].Timestamp
+	}
+	if err := h.store.CreateSeason(ctx, &season, standings); err != nil {
+		return model.Season{}, nil, err
+	}
+	committed(ctx)
+	return season, standings, nil
+}
+
+// standings ranks the keywords of entries.
+func (h *Handler) standings(ctx context.Context, source string, entries []model.Entry) []model.Standing {
+	pairs := util.EntriesToSortedMap(entries)
+	positions := util.Positions(pairs)
+	var standings []model.Standing
+	for i, pair := range pairs {
+		// the keyword may be gone already, the count is still worth keeping
+		dict, _ := h.store.GetDictionaryByKeyword(ctx, source, pair.Value)
+		standings = append(standings, model.Standing{
+			Source:      source,
+			Position:    positions[i],
+			Keyword:     pair.Value,
+			Description: dict.Description,
+			Count:       pair.Key,
+		})
+	}
+	return standings
+}
+
+// seasonName names a season after the month it ended in, e.g. 2026-09, with a
+// suffix when the month already has one.
+func (h *Handler) seasonName(ctx context.Context, group model.Group, end time.Time) (string, error) {
+	base := end.Add(-time.Second).In(util.Location(group.Timezone)).Format("2006-01")
+	name := base
+	for i := 2; ; i++ {
+		_, err := h.store.GetSeasonByName(ctx, group.Source, name)
+		if err == sql.ErrNoRows {
+			return name, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		name = base + "." + strconv.Itoa(i)
+	}
+}
+
+func (h *Handler) handleSeason(event *chat.Event, tokens []string) {
+	source := event.Source
+	group, err := h.store.GetGroup(event.Context(), source)
+	if err != nil {
+		slog.ErrorContext(event.Context(), "Cannot fetch group", logging.Err(err))
+		return
+	}
+
+	switch {
+	case len(tokens) == 1:
+		last, err := h.store.GetLastSeason(event.Context(), source)
+		if err != nil {
+			slog.ErrorContext(event.Context(), "Cannot fetch last season", logging.Err(err))
+			return
+		}
+		entries, err := h.store.GetEntriesBetween(event.Context(), source, last.EndedAt, time.Now())
+		if err != nil {
+			slog.ErrorContext(event.Context(), "Cannot fetch season entries", logging.Err(err))
+			return
+		}
+		if len(entries) == 0 {
+			h.reply(event, "No entries this season")
+			return
+		}
+		message, err := h.highscoreMessage(event.Context(), source, "This season:", entries)
+		if err != nil {
+			slog.ErrorContext(event.Context(), "Cannot rank season", logging.Err(err))
+			return
+		}
+		h.reply(event, message)
+
+	case len(tokens) == 2 && strings.ToLower(tokens[1]) == "end":
+		season, standings, err := h.endSeason(event.Context(), group, time.Now())
+		if err == errEmptySeason {
+			h.reply(event, "Nothing to archive this season")
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(event.Context(), "Cannot end season", logging.Err(err))
+			return
+		}
+		h.reply(event, seasonEndedMessage(season, standings))
+
+	case len(tokens) == 3 && strings.ToLower(tokens[1]) == "auto":
+		switch strings.ToLower(tokens[2]) {
+		case "on":
+			if !group.AutoSeason {
+				now := time.Now()
+				group.SubscribedAt = &now
+			}
+			group.AutoSeason = true
+		case "off":
+			group.AutoSeason = false
+		default:
+			return
+		}
+		if err := h.store.SaveGroup(event.Context(), &group); err != nil {
+			slog.ErrorContext(event.Context(), "Cannot save auto season", logging.Err(err))
+			return
+		}
+		committed(event.Context())
+		if group.AutoSeason {
+			h.reply(event, "Season will end automatically every month")
+		} else {
+			h.reply(event, "Season will only end with \"season end\"")
+		}
+	}
+}
+
+func (h *Handler) handleHistory(event *chat.Event, tokens []string) {
+	if len(tokens) > 2 {
+		return
+	}
+	source := event.Source
+
+	if len(tokens) == 2 {
+		season, err := h.store.GetSeasonByName(event.Context(), source, tokens[1])
+		if err != nil {
+			h.reply(event, "Season "+tokens[1]+" is not exists")
+			return
+		}
+		standings, err := h.store.GetStandings(event.Context(), season.ID)
+		if err != nil {
+			slog.ErrorContext(event.Context(), "Cannot fetch standings", "season", season.Name, logging.Err(err))
+			return
+		}
+		h.reply(event, standingsMessage("Season "+season.Name+":", standings))
+		return
+	}
+
+	seasons, err := h.store.GetSeasons(event.Context(), source)
+	if err != nil {
+		slog.ErrorContext(event.Context(), "Cannot fetch seasons", logging.Err(err))
+		return
+	}
+	if len(seasons) == 0 {
+		h.reply(event, "No season has ended yet.")
+		return
+	}
+	message := "Seasons:"
+	for _, season := range seasons {
+		message = message + "\n" + season.Name + " (" + season.StartedAt.Format("2 Jan 2006") + " - " + season.EndedAt.Format("2 Jan 2006") + ")"
+	}
+	h.reply(event, message)
+}
+
+func (h *Handler) handleChampions(event *chat.Event, tokens []string) {
+	if len(tokens) != 1 {
+		return
+	}
+	source := event.Source
+	seasons, err := h.store.GetSeasons(event.Context(), source)
+	if err != nil {
+		slog.ErrorContext(event.Context(), "Cannot fetch seasons", logging.Err(err))
+		return
+	}
+	champions, err := h.store.GetChampions(event.Context(), source)
+	if err != nil {
+		slog.ErrorContext(event.Context(), "Cannot fetch champions", logging.Err(err))
+		return
+	}
+	if len(champions) == 0 {
+		h.reply(event, "No champion yet.")
+		return
+	}
+
+	names := make(map[int]string)
+	for _, season := range seasons {
+		names[season.ID] = season.Name
+	}
+	titles := make(map[string]int)
+	message := "Champions:"
+	for _, champion := range champions {
+		titles[champion.Keyword]++
+		message = message + "\n" + names[champion.SeasonID] + ": " + champion.Keyword + " (" + strconv.Itoa(champion.Count) + ")"
+	}
+	message = message + "\n\nTitles:"
+	for _, pair := range util.MapToSortedPairs(titles) {
+		message = message + "\n" + pair.Value + " : " + strconv.Itoa(pair.Key) + "x"
+	}
+	h.reply(event, message)
+}
+
+func standingsMessage(title string, standings []model.Standing) string {
+	message := title
+	for _, st := range standings {
+		message = message + "\n" + strconv.Itoa(st.Position) + ". " + st.Keyword
+		if st.Description != "" {
+			message = message + " - " + st.Description
+		}
+		message = message + " : " + strconv.Itoa(st.Count)
+	}
+	return message
+}
+
+func seasonEndedMessage(season model.Season, standings []model.Standing) string {
+	return standingsMessage(fmt.Sprintf("Season %s has ended!", season.Name), standings)
+}
@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/luqmanarifin/kentang/model"
+	"github.com/luqmanarifin/kentang/service"
+)
+
+// unreachableSeasons fails to look seasons up, as MySQL does when it's down.
+type unreachableSeasons struct{ *service.Memory }
+
+func (unreachableSeasons) GetSeasonByName(ctx context.Context, source, name string) (model.Season, error) {
+	return model.Season{}, errors.New("connection refused")
+}
+
+func TestSeasonEndAbortsWhenNamesCantBeChecked(t *testing.T) {
+	store := unreachableSeasons{service.NewMemory()}
+	_, api, send := newLineHandler(t, store, service.NewMemoryCache())
+
+	for _, text := range []string{"add telat terlambat", "telat", "season end"} {
+		send(api.TextEvent("G1", "U1", text))
+	}
+	if seasons, _ := store.GetSeasons(context.Background(), "G1"); len(seasons) != 0 {
+		t.Errorf("archived %+v without checking the name is free", seasons)
+	}
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/line/line-bot-sdk-go/v7/linebot"
+	"github.com/luqmanarifin/kentang/card"
+	"github.com/luqmanarifin/kentang/chat"
+	"github.com/luqmanarifin/kentang/logging"
+	"github.com/luqmanarifin/kentang/util"
+)
+
+// replyCard replies with c as a flex message when the group prefers it, and
+// with text otherwise, on other platforms or when LINE rejects the flex
+// message.
+func (h *Handler) replyCard(event *chat.Event, c card.Card, text string, quick *linebot.QuickReplyItems) error {
+	source := event.Source
+	group, err := h.store.GetGroup(event.Context(), source)
+	if err != nil {
+		slog.ErrorContext(event.Context(), "Cannot fetch group", logging.Err(err))
+	}
+	if group.Style != util.STYLE_FLEX || !event.Is(chat.PlatformLINE) {
+		return h.replyQuick(event, quick, text)
+	}
+
+	var message linebot.SendingMessage = card.Flex(c, text)
+	if quick != nil {
+		message = message.WithQuickReplies(quick)
+	}
+	if err := h.replyMessages(event, message); err == nil {
+		return nil
+	}
+	// a rejected reply doesn't use up the reply token
+	return h.replyQuick(event, quick, text)
+}
+
+func (h *Handler) handleStyle(event *chat.Event, tokens []string) {
+	if len(tokens) > 2 {
+		return
+	}
+	source := event.Source
+	group, err := h.store.GetGroup(event.Context(), source)
+	if err != nil {
+		slog.ErrorContext(event.Context(), "Cannot fetch group", logging.Err(err))
+		return
+	}
+	if len(tokens) == 1 {
+		if group.Style == "" {
+			group.Style = util.STYLE_TEXT
+		}
+		h.reply(event, "Style: "+group.Style)
+		return
+	}
+
+	switch style := strings.ToLower(tokens[1]); style {
+	case util.STYLE_TEXT, util.STYLE_FLEX:
+		group.Style = style
+	default:
+		h.reply(event, "Style can be text or flex")
+		return
+	}
+	if err := h.store.SaveGroup(event.Context(), &group); err != nil {
+		slog.ErrorContext(event.Context(), "Cannot save style", logging.Err(err))
+		return
+	}
+	committed(event.Context())
+	h.reply(event, "Style set to "+group.Style)
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/luqmanarifin/kentang/chat"
+	"github.com/luqmanarifin/kentang/metrics"
+	"github.com/luqmanarifin/kentang/redact"
+	"github.com/luqmanarifin/kentang/tracing"
+)
+
+// Telegram receives updates from the Telegram Bot API webhook.
+func (h *Handler) Telegram(w http.ResponseWriter, r *http.Request) {
+	if h.telegram == nil {
+		w.WriteHeader(404)
+		return
+	}
+	_, span := tracing.Start(r.Context(), "telegram verify_request")
+	event, err := h.telegram.ParseRequest(r)
+	tracing.End(span, err)
+	if err != nil {
+		writeParseError(w, err)
+		return
+	}
+	if event != nil {
+		h.queueEvent(w, r, event)
+	}
+}
+
+// SlackEvents receives messages from the Slack Events API. Slack retries
+// events not acknowledged within 3 seconds, so they are queued.
+func (h *Handler) SlackEvents(w http.ResponseWriter, r *http.Request) {
+	if h.slack == nil {
+		w.WriteHeader(404)
+		return
+	}
+	_, span := tracing.Start(r.Context(), "slack verify_request")
+	event, challenge, err := h.slack.ParseEvent(r)
+	tracing.End(span, err)
+	if err != nil {
+		writeParseError(w, err)
+		return
+	}
+	if challenge != "" {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(challenge))
+		return
+	}
+	if event != nil {
+		h.queueEvent(w, r, event)
+	}
+}
+
+// SlackCommands receives slash commands, answered through their response
+// URL.
+func (h *Handler) SlackCommands(w http.ResponseWriter, r *http.Request) {
+	if h.slack == nil {
+		w.WriteHeader(404)
+		return
+	}
+	_, span := tracing.Start(r.Context(), "slack verify_request")
+	event, err := h.slack.ParseCommand(r)
+	tracing.End(span, err)
+	if err != nil {
+		writeParseError(w, err)
+		return
+	}
+	h.queueEvent(w, r, event)
+}
+
+// Discord receives interactions. Commands are acknowledged right away and
+// answered with follow-up messages.
+func (h *Handler) Discord(w http.ResponseWriter, r *http.Request) {
+	if h.discord == nil {
+		w.WriteHeader(404)
+		return
+	}
+	_, span := tracing.Start(r.Context(), "discord verify_request")
+	event, ping, err := h.discord.ParseRequest(r)
+	tracing.End(span, err)
+	if err != nil {
+		writeParseError(w, err)
+		return
+	}
+	if ping {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(chat.DiscordPong))
+		return
+	}
+	if event == nil {
+		w.WriteHeader(400)
+		return
+	}
+	if h.queueEvent(w, r, event) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(chat.DiscordDeferred))
+	}
+}
+
+func writeParseError(w http.ResponseWriter, err error) {
+	if err == chat.ErrInvalidSignature {
+		w.WriteHeader(401)
+	} else {
+		w.WriteHeader(400)
+	}
+}
+
+// queueEvent queues event to be handled after the request r, answering 503
+// when the queue is full so the platform tries again later.
+func (h *Handler) queueEvent(w http.ResponseWriter, r *http.Request, event *chat.Event) bool {
+	event = event.WithContext(tracing.Inherit(context.Background(), r.Context()))
+	if !h.enqueue(event.Source, func() { h.HandleEvent(event) }) {
+		w.WriteHeader(503)
+		return false
+	}
+	return true
+}
+
+// HandleEvent runs the command in a text message from a platform other
+// than LINE.
+func (h *Handler) HandleEvent(event *chat.Event) {
+	metrics.WebhookEvents.WithLabelValues(platformName(event), "message").Inc()
+	event, done := h.startEvent(event)
+	defer done()
+	slog.InfoContext(event.Context(), "Received message", "text", redact.Text(event.Text))
+	h.handleOnce(event, func(event *chat.Event) {
+		// other platforms have no profile API, remember the name they send
+		if event.UserID != "" && event.UserName != "" {
+			h.cache.SetDisplayName(event.Context(), event.UserID, event.UserName)
+		}
+		h.handleCommand(event, event.Text)
+	})
+}
@@ -0,0 +1,367 @@
+// Package linetest runs a fake LINE Messaging API for end-to-end tests. It
+// answers the reply, push and profile endpoints kentang uses, records every
+// message sent, and signs webhook requests the way LINE does.
+package linetest
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+
+	"github.com/line/line-bot-sdk-go/v7/linebot"
+)
+
+const (
+	ChannelSecret = "linetest-secret"
+	ChannelToken  = "linetest-token"
+)
+
+// Message is a message the bot sent, reduced to what tests assert on.
+type Message struct {
+	Type         string
+	Text         string
+	AltText      string
+	QuickReplies []string
+	// Postbacks maps the label of a postback quick reply to its data.
+	Postbacks map[string]string
+}
+
+// Sent is one reply or push call.
+type Sent struct {
+	ReplyToken string
+	To         string
+	Messages   []Message
+}
+
+// Texts returns the text of every message, and the alt text of flex ones.
+func (s Sent) Texts() []string {
+	var texts []string
+	for _, message := range s.Messages {
+		if message.Text != "" {
+			texts = append(texts, message.Text)
+		} else if message.AltText != "" {
+			texts = append(texts, message.AltText)
+		}
+	}
+	return texts
+}
+
+type profile struct {
+	UserID      string `json:"userId"`
+	DisplayName string `json:"displayName"`
+	PictureURL  string `json:"pictureUrl,omitempty"`
+}
+
+// Server is a fake LINE API. Reply tokens handed out by the event builders
+// can be used once, like the real ones.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	tokens   map[string]bool
+	profiles map[string]profile
+	replies  []Sent
+	pushes   []Sent
+	next     int
+}
+
+// NewServer starts a fake LINE API. Close it when done.
+func NewServer() *Server {
+	s := &Server{
+		tokens:   make(map[string]bool),
+		profiles: make(map[string]profile),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/bot/message/reply", s.handleReply)
+	mux.HandleFunc("/v2/bot/message/push", s.handlePush)
+	mux.HandleFunc("/v2/bot/profile/", s.handleProfile)
+	s.Server = httptest.NewServer(s.authorized(mux))
+	return s
+}
+
+// Client returns a LINE client talking to the fake server.
+func (s *Server) Client() *linebot.Client {
+	bot, err := linebot.New(ChannelSecret, ChannelToken, linebot.WithEndpointBase(s.URL))
+	if err != nil {
+		panic(err)
+	}
+	return bot
+}
+
+// AddProfile makes GET /v2/bot/profile/{userID} answer with name and picture.
+func (s *Server) AddProfile(userID, name, picture string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.profiles[userID] = profile{UserID: userID, DisplayName: name, PictureURL: picture}
+}
+
+// Replies returns the replies sent so far.
+func (s *Server) Replies() []Sent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Sent(nil), s.replies...)
+}
+
+// Pushes returns the pushed messages sent so far.
+func (s *Server) Pushes() []Sent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Sent(nil), s.pushes...)
+}
+
+// Reset forgets the recorded replies and pushes.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.replies, s.pushes = nil, nil
+}
+
+func (s *Server) authorized(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+ChannelToken {
+			writeError(w, http.StatusUnauthorized, "Authentication failed")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleReply(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		ReplyToken string            `json:"replyToken"`
+		Messages   []json.RawMessage `json:"messages"`
+	}
+	if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&body) != nil {
+		writeError(w, http.StatusBadRequest, "The request body has 1 error(s)")
+		return
+	}
+	messages, err := decodeMessages(body.Messages)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.tokens[body.ReplyToken] {
+		writeError(w, http.StatusBadRequest, "Invalid reply token")
+		return
+	}
+	delete(s.tokens, body.ReplyToken)
+	s.replies = append(s.replies, Sent{ReplyToken: body.ReplyToken, Messages: messages})
+	w.Write([]byte("{}"))
+}
+
+func (s *Server) handlePush(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		To       string            `json:"to"`
+		Messages []json.RawMessage `json:"messages"`
+	}
+	if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&body) != nil || body.To == "" {
+		writeError(w, http.StatusBadRequest, "The request body has 1 error(s)")
+		return
+	}
+	messages, err := decodeMessages(body.Messages)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pushes = append(s.pushes, Sent{To: body.To, Messages: messages})
+	w.Write([]byte("{}"))
+}
+
+func (s *Server) handleProfile(w http.ResponseWriter, r *http.Request) {
+	userID := strings.TrimPrefix(r.URL.Path, "/v2/bot/profile/")
+	s.mu.Lock()
+	p, ok := s.profiles[userID]
+	s.mu.Unlock()
+	if r.Method != http.MethodGet || !ok {
+		writeError(w, http.StatusNotFound, "Not found")
+		return
+	}
+	json.NewEncoder(w).Encode(p)
+}
+
+func decodeMessages(raw []json.RawMessage) ([]Message, error) {
+	if len(raw) == 0 || len(raw) > 5 {
+		return nil, fmt.Errorf("Size must be between 1 and 5")
+	}
+	var messages []Message
+	for _, r := range raw {
+		var m struct {
+			Type       string `json:"type"`
+			Text       string `json:"text"`
+			AltText    string `json:"altText"`
+			QuickReply *struct {
+				Items []struct {
+					Action struct {
+						Type  string `json:"type"`
+						Label string `json:"label"`
+						Data  string `json:"data"`
+					} `json:"action"`
+				} `json:"items"`
+			} `json:"quickReply"`
+		}
+		if err := json.Unmarshal(r, &m); err != nil {
+			return nil, err
+		}
+		if m.Type == "" {
+			return nil, fmt.Errorf("Message type is missing")
+		}
+		message := Message{Type: m.Type, Text: m.Text, AltText: m.AltText}
+		if m.QuickReply != nil {
+			for _, item := range m.QuickReply.Items {
+				message.QuickReplies = append(message.QuickReplies, item.Action.Label)
+				if item.Action.Type == "postback" {
+					if message.Postbacks == nil {
+						message.Postbacks = make(map[string]string)
+					}
+					message.Postbacks[item.Action.Label] = item.Action.Data
+				}
+			}
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
+
+// Event is a webhook event as LINE sends it.
+type Event map[string]interface{}
+
+// newEvent fills in what every event has, with a fresh reply token.
+func (s *Server) newEvent(eventType, source, userID string) Event {
+	s.mu.Lock()
+	s.next++
+	id := fmt.Sprintf("%08d", s.next)
+	token := "reply-" + id
+	s.tokens[token] = true
+	s.mu.Unlock()
+
+	src := map[string]interface{}{"type": "user", "userId": userID}
+	if source != userID {
+		src["type"] = "group"
+		src["groupId"] = source
+	}
+	return Event{
+		"type":            eventType,
+		"replyToken":      token,
+		"source":          src,
+		"timestamp":       time.Now().UnixNano() / int64(time.Millisecond),
+		"mode":            "active",
+		"webhookEventId":  "01LINETEST" + id,
+		"deliveryContext": map[string]interface{}{"isRedelivery": false},
+	}
+}
+
+// TextEvent is userID typing text in source, a group or, when it's the same
+// as userID, a one-on-one chat.
+func (s *Server) TextEvent(source, userID, text string) Event {
+	e := s.newEvent("message", source, userID)
+	e["message"] = map[string]interface{}{"id": e["webhookEventId"], "type": "text", "text": text}
+	return e
+}
+
+// MentionEvent is userID sending text after mentioning mentionedID, who the
+// group knows as name.
+func (s *Server) MentionEvent(source, userID, mentionedID, name, text string) Event {
+	mention := "@" + name
+	e := s.TextEvent(source, userID, mention+" "+text)
+	e["message"].(map[string]interface{})["mention"] = map[string]interface{}{
+		"mentionees": []map[string]interface{}{{
+			"index":  0,
+			"length": len(utf16.Encode([]rune(mention))),
+			"type":   "user",
+			"userId": mentionedID,
+		}},
+	}
+	return e
+}
+
+// StickerEvent is userID sending the sticker stickerID of packageID.
+func (s *Server) StickerEvent(source, userID, packageID, stickerID string) Event {
+	e := s.newEvent("message", source, userID)
+	e["message"] = map[string]interface{}{"id": e["webhookEventId"], "type": "sticker", "packageId": packageID, "stickerId": stickerID}
+	return e
+}
+
+// ImageEvent is userID sending an image.
+func (s *Server) ImageEvent(source, userID string) Event {
+	e := s.newEvent("message", source, userID)
+	e["message"] = map[string]interface{}{"id": e["webhookEventId"], "type": "image", "contentProvider": map[string]interface{}{"type": "line"}}
+	return e
+}
+
+// PostbackEvent is userID tapping a postback button with data.
+func (s *Server) PostbackEvent(source, userID, data string) Event {
+	e := s.newEvent("postback", source, userID)
+	e["postback"] = map[string]interface{}{"data": data}
+	return e
+}
+
+// JoinEvent is the bot joining source.
+func (s *Server) JoinEvent(source string) Event {
+	e := s.newEvent("join", source, "")
+	delete(e["source"].(map[string]interface{}), "userId")
+	return e
+}
+
+// LeaveEvent is the bot being removed from source. Like LINE, it has no
+// reply token.
+func (s *Server) LeaveEvent(source string) Event {
+	e := s.newEvent("leave", source, "")
+	delete(e["source"].(map[string]interface{}), "userId")
+	delete(e, "replyToken")
+	return e
+}
+
+// Redelivery is e as LINE sends it again after the bot failed to answer,
+// with the same webhook event ID.
+func Redelivery(e Event) Event {
+	again := make(Event, len(e))
+	for k, v := range e {
+		again[k] = v
+	}
+	again["deliveryContext"] = map[string]interface{}{"isRedelivery": true}
+	return again
+}
+
+// Webhook builds the callback request LINE would send for events, signed
+// with the channel secret.
+func (s *Server) Webhook(events ...Event) *http.Request {
+	body, err := json.Marshal(map[string]interface{}{
+		"destination": "Ulinetest",
+		"events":      events,
+	})
+	if err != nil {
+		panic(err)
+	}
+	r := httptest.NewRequest(http.MethodPost, "/callback", bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("X-Line-Signature", Sign(ChannelSecret, body))
+	return r
+}
+
+// Sign returns the X-Line-Signature of body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
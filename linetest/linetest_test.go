@@ -0,0 +1,45 @@
+package linetest
+
+import (
+	"testing"
+
+	"github.com/line/line-bot-sdk-go/v7/linebot"
+)
+
+func TestReplyTokenIsSingleUse(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	bot := s.Client()
+
+	token := s.TextEvent("G1", "U1", "hi")["replyToken"].(string)
+	if _, err := bot.ReplyMessage(token, linebot.NewTextMessage("hello")).Do(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bot.ReplyMessage(token, linebot.NewTextMessage("again")).Do(); err == nil {
+		t.Error("reused reply token was accepted")
+	}
+	if _, err := bot.ReplyMessage("made-up", linebot.NewTextMessage("hello")).Do(); err == nil {
+		t.Error("unknown reply token was accepted")
+	}
+
+	replies := s.Replies()
+	if len(replies) != 1 || replies[0].Texts()[0] != "hello" {
+		t.Errorf("got replies %+v", replies)
+	}
+}
+
+func TestWebhookIsSigned(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	events, err := s.Client().ParseRequest(s.Webhook(s.TextEvent("G1", "U1", "list")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Source.GroupID != "G1" || events[0].Source.UserID != "U1" {
+		t.Fatalf("got events %+v", events)
+	}
+	if m, ok := events[0].Message.(*linebot.TextMessage); !ok || m.Text != "list" {
+		t.Errorf("got message %+v", events[0].Message)
+	}
+}
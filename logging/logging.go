@@ -0,0 +1,90 @@
+// Package logging sets up log/slog and carries the fields that tie the log
+// lines of one event together in a context.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync/atomic"
+)
+
+type fieldsKey struct{}
+
+// With returns a copy of ctx whose log records carry args, as key value
+// pairs like slog.Info takes, on top of the ones ctx carries already.
+func With(ctx context.Context, args ...any) context.Context {
+	fields, _ := ctx.Value(fieldsKey{}).([]any)
+	return context.WithValue(ctx, fieldsKey{}, append(fields[:len(fields):len(fields)], args...))
+}
+
+type errorsKey struct{}
+
+// CountErrors returns a copy of ctx that counts the errors logged with it,
+// and a func telling how many were so far. Only loggers made by New count.
+func CountErrors(ctx context.Context) (context.Context, func() int) {
+	count := new(int32)
+	return context.WithValue(ctx, errorsKey{}, count), func() int {
+		return int(atomic.LoadInt32(count))
+	}
+}
+
+// contextHandler adds the fields of the record's context.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if count, ok := ctx.Value(errorsKey{}).(*int32); ok && r.Level >= slog.LevelError {
+		atomic.AddInt32(count, 1)
+	}
+	if fields, ok := ctx.Value(fieldsKey{}).([]any); ok {
+		r.Add(fields...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// New makes a logger writing to w as "json" or "text", from level up, that
+// adds the fields carried by the context of each record.
+func New(w io.Writer, format, level string) (*slog.Logger, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("log level: %v", err)
+	}
+	opts := &slog.HandlerOptions{Level: l}
+	var h slog.Handler
+	switch format {
+	case "json":
+		h = slog.NewJSONHandler(w, opts)
+	case "text":
+		h = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("log format %q is neither json nor text", format)
+	}
+	return slog.New(contextHandler{h}), nil
+}
+
+// Setup makes New's logger the default one, which the log package writes
+// through too.
+func Setup(w io.Writer, format, level string) error {
+	logger, err := New(w, format, level)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+// Err is the attribute errors are logged with.
+func Err(err error) slog.Attr {
+	return slog.Any("err", err)
+}
@@ -0,0 +1,59 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestWith(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := New(&out, "json", "info")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := With(context.Background(), "source", "G1")
+	other := With(ctx, "command", "list")
+	With(ctx, "command", "add")
+	logger.InfoContext(other, "replied", "messages", 2)
+	logger.DebugContext(other, "not shown")
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatalf("%v: %s", err, out.String())
+	}
+	want := map[string]interface{}{"msg": "replied", "source": "G1", "command": "list", "messages": 2.0}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("%s: got %v, want %v", key, record[key], value)
+		}
+	}
+	if bytes.Count(out.Bytes(), []byte("\n")) != 1 {
+		t.Errorf("debug record written at info level: %s", out.String())
+	}
+}
+
+func TestNewRejectsUnknown(t *testing.T) {
+	if _, err := New(&bytes.Buffer{}, "xml", "info"); err == nil {
+		t.Error("accepted format xml")
+	}
+	if _, err := New(&bytes.Buffer{}, "text", "loud"); err == nil {
+		t.Error("accepted level loud")
+	}
+}
+
+func TestCountErrors(t *testing.T) {
+	logger, err := New(&bytes.Buffer{}, "text", "info")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, errors := CountErrors(context.Background())
+	logger.WarnContext(ctx, "slow")
+	logger.ErrorContext(With(ctx, "keyword", "telat"), "cannot count")
+	logger.Error("no context")
+	if got := errors(); got != 1 {
+		t.Errorf("counted %d errors, want 1", got)
+	}
+}
@@ -1,39 +1,111 @@
 package main
 
 import (
+	"context"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	_ "time/tzdata"
 
 	_ "github.com/go-sql-driver/mysql"
-	"github.com/joho/godotenv"
+	"github.com/luqmanarifin/kentang/config"
 	"github.com/luqmanarifin/kentang/handler"
+	"github.com/luqmanarifin/kentang/logging"
+	"github.com/luqmanarifin/kentang/metrics"
+	"github.com/luqmanarifin/kentang/redact"
+	"github.com/luqmanarifin/kentang/repl"
+	"github.com/luqmanarifin/kentang/server"
+	"github.com/luqmanarifin/kentang/tracing"
 )
 
 func main() {
-	if os.Getenv("APP_ENV") != "production" {
-		err := godotenv.Load()
-		if err != nil {
-			log.Fatal("Error loading .env file")
+	if len(os.Args) > 1 && os.Args[1] == "repl" {
+		if err := repl.Main(os.Args[2:], os.Stdin, os.Stdout); err != nil {
+			log.Fatal(err)
 		}
+		return
 	}
 
-	log.Printf("channel secret %s\n", os.Getenv("CHANNEL_SECRET"))
-	log.Printf("channel token %s\n", os.Getenv("CHANNEL_TOKEN"))
-	log.Printf("port %s\n", os.Getenv("PORT"))
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := logging.Setup(os.Stderr, cfg.Log.Format, cfg.Log.Level); err != nil {
+		log.Fatal(err)
+	}
 
-	handler := handler.NewHandler(
-		os.Getenv("CHANNEL_SECRET"),
-		os.Getenv("CHANNEL_TOKEN"),
-	)
+	redact.ShowContent = cfg.Log.UserContent
+	slog.Info("Loaded config", "config", cfg.String())
 
-	http.HandleFunc("/", handler.Index)
-	http.HandleFunc("/healthz", handler.Healthz)
-	http.HandleFunc("/callback", handler.Callback)
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing.Endpoint, cfg.Tracing.ServiceName)
+	if err != nil {
+		slog.Error("Cannot set up tracing", logging.Err(err))
+		os.Exit(1)
+	}
 
-	// This is just sample code.
-	// For actual use, you must support HTTPS by using `ListenAndServeTLS`, a reverse proxy or something else.
-	if err := http.ListenAndServe(":"+os.Getenv("PORT"), nil); err != nil {
-		log.Fatal(err)
+	handler, err := handler.NewHandler(cfg)
+	if err != nil {
+		slog.Error("Cannot start", logging.Err(err))
+		os.Exit(1)
+	}
+
+	metrics.WatchQueue(handler.QueueStats)
+
+	scheduler := handler.Scheduler()
+	scheduler.Start()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", handler.Index)
+	mux.HandleFunc("/healthz", handler.Healthz)
+	mux.HandleFunc("/readyz", handler.Readyz)
+	mux.Handle("/metrics", metrics.Handler())
+	for route, webhook := range map[string]http.HandlerFunc{
+		"/callback":       handler.Callback,
+		"/telegram":       handler.Telegram,
+		"/slack/events":   handler.SlackEvents,
+		"/slack/commands": handler.SlackCommands,
+		"/discord":        handler.Discord,
+	} {
+		mux.Handle(route, tracing.Handler(route, webhook))
+	}
+	mux.HandleFunc("/chart/", handler.Chart)
+	mux.Handle("/api/", tracing.Handler("/api/", http.HandlerFunc(handler.Admin)))
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// after the last request, finish the queued events, then close MySQL and
+	// Redis once the scheduler doesn't need them either, and send the last
+	// spans
+	err = server.Run(ctx, serverConfig(cfg), mux,
+		handler.Drain,
+		func(context.Context) error {
+			scheduler.Stop()
+			return nil
+		},
+		func(context.Context) error {
+			return handler.Close()
+		},
+		shutdownTracing,
+	)
+	if err != nil {
+		slog.Error("Cannot serve", logging.Err(err))
+		os.Exit(1)
+	}
+}
+
+func serverConfig(cfg config.Config) server.Config {
+	return server.Config{
+		Addr:              ":" + cfg.Port,
+		ReadTimeout:       cfg.HTTP.ReadTimeout,
+		ReadHeaderTimeout: cfg.HTTP.ReadHeaderTimeout,
+		WriteTimeout:      cfg.HTTP.WriteTimeout,
+		IdleTimeout:       cfg.HTTP.IdleTimeout,
+		ShutdownTimeout:   cfg.HTTP.ShutdownTimeout,
+		CertFile:          cfg.HTTP.TLSCertFile,
+		KeyFile:           cfg.HTTP.TLSKeyFile,
 	}
 }
@@ -0,0 +1,185 @@
+// Package metrics holds the Prometheus metrics of the bot and serves them on
+// /metrics.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/luqmanarifin/kentang/queue"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "kentang"
+
+// Registry holds every metric of the bot, along with the Go runtime and
+// process ones.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// WebhookEvents counts the events received, by platform and event type.
+	WebhookEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_events_total",
+		Help:      "Webhook events received, redeliveries included.",
+	}, []string{"platform", "type"})
+
+	// Commands counts the handled commands by name and outcome, which is
+	// error when handling it logged an error.
+	Commands = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "commands_total",
+		Help:      "Commands handled, by outcome.",
+	}, []string{"command", "outcome"})
+
+	// KeywordEntries counts the keywords counted.
+	KeywordEntries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "keyword_entries_total",
+		Help:      "Keyword counts recorded.",
+	}, []string{"platform"})
+
+	LINERequests = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "line_api_request_duration_seconds",
+		Help:      "Latency of LINE Messaging API calls.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"call"})
+
+	LINEErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "line_api_errors_total",
+		Help:      "LINE Messaging API calls that failed, by HTTP status or error when no response came.",
+	}, []string{"call", "code"})
+
+	MySQLQueries = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "mysql_query_duration_seconds",
+		Help:      "Latency of MySQL queries, by statement and table.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"op", "status"})
+
+	RedisCommands = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "redis_command_duration_seconds",
+		Help:      "Latency of Redis commands.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25},
+	}, []string{"command", "status"})
+
+	// CacheLookups counts the Redis lookups that found a value and the ones
+	// that have to go to MySQL or the LINE API.
+	CacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_lookups_total",
+		Help:      "Redis cache lookups, by cache and hit or miss.",
+	}, []string{"cache", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		WebhookEvents,
+		Commands,
+		KeywordEntries,
+		LINERequests,
+		LINEErrors,
+		MySQLQueries,
+		RedisCommands,
+		CacheLookups,
+	)
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// Status is the status label of a query or command.
+func Status(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
+
+// Cache records a lookup in cache, hit when it found a value.
+func Cache(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	CacheLookups.WithLabelValues(cache, result).Inc()
+}
+
+// WatchQueue exports the stats of the webhook queue. Call it once.
+func WatchQueue(stats func() queue.Stats) {
+	gauge := func(name, help string, value func(queue.Stats) float64) prometheus.Collector {
+		return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace, Subsystem: "queue", Name: name, Help: help,
+		}, func() float64 { return value(stats()) })
+	}
+	counter := func(name, help string, value func(queue.Stats) uint64) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: "queue", Name: name, Help: help,
+		}, func() float64 { return float64(value(stats())) })
+	}
+	Registry.MustRegister(
+		gauge("capacity", "Jobs the queue holds at most.", func(s queue.Stats) float64 { return float64(s.Capacity) }),
+		gauge("depth", "Jobs waiting for a worker.", func(s queue.Stats) float64 { return float64(s.Depth) }),
+		gauge("max_wait_seconds", "Longest a job waited for a worker.", func(s queue.Stats) float64 { return s.MaxWait.Seconds() }),
+		counter("submitted_total", "Jobs queued.", func(s queue.Stats) uint64 { return s.Submitted }),
+		counter("processed_total", "Jobs run.", func(s queue.Stats) uint64 { return s.Processed }),
+		counter("rejected_total", "Jobs turned away because the queue was full or closed.", func(s queue.Stats) uint64 { return s.Rejected }),
+		counter("panics_total", "Jobs that panicked.", func(s queue.Stats) uint64 { return s.Panics }),
+	)
+}
+
+// Transport times the LINE API calls made through next, and counts the ones
+// that fail.
+func Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return roundTripper(func(r *http.Request) (*http.Response, error) {
+		call := LINECall(r.URL.Path)
+		start := time.Now()
+		resp, err := next.RoundTrip(r)
+		LINERequests.WithLabelValues(call).Observe(time.Since(start).Seconds())
+		if err != nil {
+			LINEErrors.WithLabelValues(call, "error").Inc()
+		} else if resp.StatusCode >= 400 {
+			LINEErrors.WithLabelValues(call, strconv.Itoa(resp.StatusCode)).Inc()
+		}
+		return resp, err
+	})
+}
+
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// LINECall names the LINE API call of path, leaving out the IDs in it.
+func LINECall(path string) string {
+	path = strings.TrimPrefix(path, "/v2/bot/")
+	switch {
+	case path == "message/reply", path == "message/push", path == "message/multicast":
+		return strings.TrimPrefix(path, "message/")
+	case strings.HasPrefix(path, "profile/"):
+		return "profile"
+	case strings.HasPrefix(path, "group/"), strings.HasPrefix(path, "room/"):
+		// e.g. group/{groupId}/member/{userId}
+		parts := strings.Split(path, "/")
+		if len(parts) < 3 {
+			return parts[0]
+		}
+		return parts[0] + "_" + parts[2]
+	}
+	return "other"
+}
@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/luqmanarifin/kentang/queue"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestLineCall(t *testing.T) {
+	for path, want := range map[string]string{
+		"/v2/bot/message/reply":         "reply",
+		"/v2/bot/message/push":          "push",
+		"/v2/bot/profile/U123":          "profile",
+		"/v2/bot/group/G1/member/U123":  "group_member",
+		"/v2/bot/group/G1/summary":      "group_summary",
+		"/v2/bot/message/12345/content": "other",
+		"/v2/bot/richmenu/list":         "other",
+	} {
+		if got := LINECall(path); got != want {
+			t.Errorf("LINECall(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/bot/message/push" {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: Transport(nil)}
+
+	before := testutil.ToFloat64(LINEErrors.WithLabelValues("push", "429"))
+	for _, path := range []string{"/v2/bot/message/reply", "/v2/bot/message/push"} {
+		resp, err := client.Post(server.URL+path, "application/json", strings.NewReader("{}"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if got := testutil.ToFloat64(LINEErrors.WithLabelValues("push", "429")) - before; got != 1 {
+		t.Errorf("counted %v push errors, want 1", got)
+	}
+	if got := testutil.ToFloat64(LINEErrors.WithLabelValues("reply", "200")); got != 0 {
+		t.Errorf("counted %v reply errors, want 0", got)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	WatchQueue(func() queue.Stats { return queue.Stats{Capacity: 256, Depth: 3, Rejected: 1} })
+	Commands.WithLabelValues("add", "ok").Inc()
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+	for _, want := range []string{
+		`kentang_commands_total{command="add",outcome="ok"} 1`,
+		"kentang_queue_depth 3",
+		"kentang_queue_rejected_total 1",
+		"go_goroutines",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics lack %q", want)
+		}
+	}
+}
@@ -0,0 +1,3 @@
+-- Keywords and entries counted against a mentioned group member.
+ALTER TABLE dictionaries ADD COLUMN target VARCHAR(64) NOT NULL DEFAULT '' AFTER creator;
+ALTER TABLE entries ADD COLUMN target VARCHAR(64) NOT NULL DEFAULT '' AFTER keyword;
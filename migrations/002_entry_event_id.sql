@@ -0,0 +1,3 @@
+-- The webhook event an entry was counted from, so a redelivered event isn't
+-- counted twice.
+ALTER TABLE entries ADD COLUMN event_id VARCHAR(128) NULL DEFAULT NULL AFTER target, ADD UNIQUE KEY event_id (event_id);
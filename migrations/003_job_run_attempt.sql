@@ -0,0 +1,3 @@
+-- Which attempt at its period a job run was, so failed runs are retried a
+-- bounded number of times.
+ALTER TABLE job_runs ADD COLUMN attempt INT NOT NULL DEFAULT 1 AFTER error;
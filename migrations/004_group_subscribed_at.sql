@@ -0,0 +1,5 @@
+-- When recap or auto season was turned on, which the scheduler compares
+-- against instead of the timestamp every settings change moves. Groups that
+-- already subscribed count from their last change.
+ALTER TABLE group_settings ADD COLUMN subscribed_at DATETIME NULL DEFAULT NULL AFTER left_at;
+UPDATE group_settings SET subscribed_at = timestamp WHERE recap <> '' OR auto_season = 1;
@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+type Badge struct {
+	ID        int       `json:"id"`
+	Source    string    `json:"source"`
+	Keyword   string    `json:"keyword"`
+	Name      string    `json:"name"`
+	Timestamp time.Time `json:"timestamp"`
+}
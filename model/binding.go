@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// Binding makes a non-text message, like a sticker, run a command as if it
+// was typed.
+type Binding struct {
+	ID        int       `json:"id"`
+	Source    string    `json:"source"`
+	Type      string    `json:"type"`
+	Key       string    `json:"key"`
+	Command   string    `json:"command"`
+	Creator   string    `json:"creator"`
+	Timestamp time.Time `json:"timestamp"`
+}
@@ -8,5 +8,6 @@ type Dictionary struct {
 	Keyword     string    `json:"keyword"`
 	Description string    `json:"description"`
 	Creator     string    `json:"creator"`
+	Target      string    `json:"target"`
 	Timestamp   time.Time `json:"timestamp"`
 }
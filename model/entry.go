@@ -6,5 +6,7 @@ type Entry struct {
 	ID        int       `json:"id"`
 	Source    string    `json:"source"`
 	Keyword   string    `json:"keyword"`
+	Target    string    `json:"target"`
+	EventID   string    `json:"event_id,omitempty"`
 	Timestamp time.Time `json:"timestamp"`
 }
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+type Group struct {
+	Source       string     `json:"source"`
+	Timezone     string     `json:"timezone"`
+	Recap        string     `json:"recap"`
+	AutoSeason   bool       `json:"auto_season"`
+	Style        string     `json:"style"`
+	LeftAt       *time.Time `json:"left_at"`
+	SubscribedAt *time.Time `json:"subscribed_at"`
+	Timestamp    time.Time  `json:"timestamp"`
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+type JobRun struct {
+	ID          int       `json:"id"`
+	Job         string    `json:"job"`
+	Source      string    `json:"source"`
+	Owner       string    `json:"owner"`
+	Status      string    `json:"status"`
+	Error       string    `json:"error"`
+	Attempt     int       `json:"attempt"`
+	ScheduledAt time.Time `json:"scheduled_at"`
+	StartedAt   time.Time `json:"started_at"`
+	FinishedAt  time.Time `json:"finished_at"`
+}
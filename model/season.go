@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+type Season struct {
+	ID        int       `json:"id"`
+	Source    string    `json:"source"`
+	Name      string    `json:"name"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+}
+
+// Standing is a keyword's final position in an archived season. The
+// description is copied so the archive outlives the dictionary.
+type Standing struct {
+	ID          int    `json:"id"`
+	SeasonID    int    `json:"season_id"`
+	Source      string `json:"source"`
+	Position    int    `json:"position"`
+	Keyword     string `json:"keyword"`
+	Description string `json:"description"`
+	Count       int    `json:"count"`
+}
@@ -0,0 +1,181 @@
+// Package queue runs webhook work off the request path. Jobs with the same
+// key always go to the same worker, so the messages of one group are handled
+// in the order they came in while groups don't wait for each other.
+package queue
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrFull is returned by Submit when the key's worker has no room left.
+	ErrFull = errors.New("queue is full")
+	// ErrClosed is returned by Submit once the queue is draining.
+	ErrClosed = errors.New("queue is closed")
+)
+
+type job struct {
+	run      func()
+	queuedAt time.Time
+}
+
+// Stats tells how far behind the workers are.
+type Stats struct {
+	Workers   int           `json:"workers"`
+	Capacity  int           `json:"capacity"`
+	Depth     int           `json:"depth"`
+	Submitted uint64        `json:"submitted"`
+	Processed uint64        `json:"processed"`
+	Rejected  uint64        `json:"rejected"`
+	Panics    uint64        `json:"panics"`
+	MaxWait   time.Duration `json:"max_wait_ns"`
+}
+
+type Queue struct {
+	workers []chan job
+	size    int
+
+	mu      sync.RWMutex
+	closed  bool
+	pending sync.WaitGroup
+	done    sync.WaitGroup
+
+	depth     int64
+	submitted uint64
+	processed uint64
+	rejected  uint64
+	panics    uint64
+	maxWait   int64
+}
+
+// New starts workers goroutines, each holding up to size waiting jobs.
+func New(workers, size int) *Queue {
+	if workers < 1 {
+		workers = 1
+	}
+	if size < 1 {
+		size = 1
+	}
+	q := &Queue{workers: make([]chan job, workers), size: size}
+	for i := range q.workers {
+		q.workers[i] = make(chan job, size)
+		q.done.Add(1)
+		go q.work(q.workers[i])
+	}
+	return q
+}
+
+// Submit queues run behind the other jobs of key. It doesn't wait for room:
+// a full worker means the bot is behind, and the caller should tell the
+// sender to retry later.
+func (q *Queue) Submit(key string, run func()) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		atomic.AddUint64(&q.rejected, 1)
+		return ErrClosed
+	}
+	q.pending.Add(1)
+	atomic.AddInt64(&q.depth, 1)
+	select {
+	case q.workers[q.index(key)] <- job{run: run, queuedAt: time.Now()}:
+		atomic.AddUint64(&q.submitted, 1)
+		return nil
+	default:
+		atomic.AddInt64(&q.depth, -1)
+		q.pending.Done()
+		atomic.AddUint64(&q.rejected, 1)
+		return ErrFull
+	}
+}
+
+func (q *Queue) index(key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(len(q.workers)))
+}
+
+func (q *Queue) work(jobs chan job) {
+	defer q.done.Done()
+	for j := range jobs {
+		atomic.AddInt64(&q.depth, -1)
+		wait := int64(time.Since(j.queuedAt))
+		for {
+			max := atomic.LoadInt64(&q.maxWait)
+			if wait <= max || atomic.CompareAndSwapInt64(&q.maxWait, max, wait) {
+				break
+			}
+		}
+		q.run(j)
+	}
+}
+
+func (q *Queue) run(j job) {
+	defer q.pending.Done()
+	defer atomic.AddUint64(&q.processed, 1)
+	defer func() {
+		if r := recover(); r != nil {
+			atomic.AddUint64(&q.panics, 1)
+			slog.Error("Job panicked", "panic", r, "stack", string(debug.Stack()))
+		}
+	}()
+	j.run()
+}
+
+// Wait blocks until every submitted job has run. It's meant for tests, which
+// submit and then wait from the same goroutine.
+func (q *Queue) Wait() {
+	q.pending.Wait()
+}
+
+// Drain stops taking jobs and waits for the queued ones to finish, or for
+// ctx to be done.
+func (q *Queue) Drain(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		for _, jobs := range q.workers {
+			close(jobs)
+		}
+	}
+	q.mu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		q.done.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Closed tells whether Drain was called.
+func (q *Queue) Closed() bool {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.closed
+}
+
+func (q *Queue) Stats() Stats {
+	return Stats{
+		Workers:   len(q.workers),
+		Capacity:  len(q.workers) * q.size,
+		Depth:     int(atomic.LoadInt64(&q.depth)),
+		Submitted: atomic.LoadUint64(&q.submitted),
+		Processed: atomic.LoadUint64(&q.processed),
+		Rejected:  atomic.LoadUint64(&q.rejected),
+		Panics:    atomic.LoadUint64(&q.panics),
+		MaxWait:   time.Duration(atomic.LoadInt64(&q.maxWait)),
+	}
+}
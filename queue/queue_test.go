@@ -0,0 +1,91 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSubmitKeepsOrderPerKey(t *testing.T) {
+	q := New(4, 100)
+	var mu sync.Mutex
+	got := make(map[string][]int)
+	for i := 0; i < 50; i++ {
+		for _, key := range []string{"a", "b", "c"} {
+			key, i := key, i
+			if err := q.Submit(key, func() {
+				mu.Lock()
+				got[key] = append(got[key], i)
+				mu.Unlock()
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	q.Wait()
+	for key, order := range got {
+		for i, n := range order {
+			if n != i {
+				t.Fatalf("%s ran out of order: %v", key, order)
+			}
+		}
+	}
+	if stats := q.Stats(); stats.Processed != 150 || stats.Depth != 0 {
+		t.Errorf("got stats %+v", stats)
+	}
+}
+
+func TestSubmitRejectsWhenFull(t *testing.T) {
+	q := New(1, 1)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	q.Submit("a", func() { close(started); <-release })
+	<-started
+	if err := q.Submit("a", func() {}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Submit("a", func() {}); err != ErrFull {
+		t.Errorf("got %v, want ErrFull", err)
+	}
+	if stats := q.Stats(); stats.Rejected != 1 || stats.Depth != 1 {
+		t.Errorf("got stats %+v", stats)
+	}
+	close(release)
+	q.Wait()
+}
+
+func TestDrain(t *testing.T) {
+	q := New(2, 10)
+	done := 0
+	var mu sync.Mutex
+	for i := 0; i < 10; i++ {
+		q.Submit(fmt.Sprint(i), func() {
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			done++
+			mu.Unlock()
+		})
+	}
+	if err := q.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if done != 10 {
+		t.Errorf("drained with %d of 10 jobs done", done)
+	}
+	if err := q.Submit("a", func() {}); err != ErrClosed {
+		t.Errorf("got %v after drain, want ErrClosed", err)
+	}
+}
+
+func TestPanicKeepsWorker(t *testing.T) {
+	q := New(1, 10)
+	ran := false
+	q.Submit("a", func() { panic("boom") })
+	q.Submit("a", func() { ran = true })
+	q.Wait()
+	if !ran || q.Stats().Panics != 1 {
+		t.Errorf("ran %v, stats %+v", ran, q.Stats())
+	}
+}
@@ -0,0 +1,43 @@
+// Package redact keeps credentials and what users write out of the logs,
+// while leaving enough to tell what a line is about.
+package redact
+
+import (
+	"fmt"
+	"net/url"
+	"unicode/utf8"
+)
+
+const Mask = "[REDACTED]"
+
+// ShowContent logs what users write as is, for debugging locally.
+var ShowContent = false
+
+// Secret masks a credential, leaving only whether it is set.
+func Secret(s string) string {
+	if s == "" {
+		return ""
+	}
+	return Mask
+}
+
+// URL masks the password of a connection URL, keeping where it points to.
+func URL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return Mask
+	}
+	return u.Redacted()
+}
+
+// Text stands for a message a user wrote, showing only its length unless
+// ShowContent is set.
+func Text(s string) string {
+	if ShowContent {
+		return s
+	}
+	return fmt.Sprintf("[%d chars]", utf8.RuneCountInString(s))
+}
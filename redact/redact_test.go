@@ -0,0 +1,36 @@
+package redact
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSecret(t *testing.T) {
+	if got := Secret(""); got != "" {
+		t.Errorf("got %q for an empty secret", got)
+	}
+	if got := Secret("hunter2"); got != Mask {
+		t.Errorf("got %q, want the mask", got)
+	}
+}
+
+func TestURL(t *testing.T) {
+	got := URL("redis://:hunter2@cache.internal:6379/0")
+	if strings.Contains(got, "hunter2") || !strings.Contains(got, "cache.internal:6379") {
+		t.Errorf("got %q", got)
+	}
+	if got := URL("redis://cache.internal:6379"); got != "redis://cache.internal:6379" {
+		t.Errorf("got %q for a URL without password", got)
+	}
+}
+
+func TestText(t *testing.T) {
+	if got := Text("telat lagi"); got != "[10 chars]" {
+		t.Errorf("got %q", got)
+	}
+	ShowContent = true
+	defer func() { ShowContent = false }()
+	if got := Text("telat lagi"); got != "telat lagi" {
+		t.Errorf("got %q with ShowContent", got)
+	}
+}
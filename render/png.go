@@ -0,0 +1,154 @@
+package render
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strconv"
+	"time"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	pngWidth   = 1040
+	pngPadding = 32
+	textScale  = 2
+	lineHeight = 13 * textScale
+)
+
+var (
+	background = color.RGBA{0xff, 0xfb, 0xf0, 0xff}
+	foreground = color.RGBA{0x33, 0x2b, 0x1e, 0xff}
+	potato     = color.RGBA{0xe8, 0xb3, 0x3c, 0xff}
+	faded      = color.RGBA{0xd9, 0xcf, 0xbd, 0xff}
+)
+
+// BarChartPNG draws a leaderboard with one horizontal bar per value.
+func BarChartPNG(title string, labels []string, values []int) ([]byte, error) {
+	const rowHeight = lineHeight + 20
+	labelWidth := 0
+	for _, l := range labels {
+		if w := textWidth(l); w > labelWidth {
+			labelWidth = w
+		}
+	}
+	max := 0
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+	}
+
+	height := 2*pngPadding + 2*lineHeight + len(values)*rowHeight
+	img := canvas(pngWidth, height)
+	drawText(img, pngPadding, pngPadding, title, foreground)
+
+	barLeft := pngPadding + labelWidth + 16
+	barSpace := pngWidth - barLeft - pngPadding - textWidth(strconv.Itoa(max)) - 16
+	for i, v := range values {
+		top := pngPadding + 2*lineHeight + i*rowHeight
+		drawText(img, pngPadding, top+(rowHeight-lineHeight)/2, labels[i], foreground)
+
+		w := 0
+		if max > 0 {
+			w = v * barSpace / max
+		}
+		fill(img, image.Rect(barLeft, top+4, barLeft+w, top+rowHeight-4), potato)
+		drawText(img, barLeft+w+8, top+(rowHeight-lineHeight)/2, strconv.Itoa(v), foreground)
+	}
+	return encode(img)
+}
+
+// SeriesPNG draws daily counts as columns, the last one being end.
+func SeriesPNG(title string, values []int, end time.Time) ([]byte, error) {
+	const plotHeight = 360
+	height := 2*pngPadding + 2*lineHeight + plotHeight + 2*lineHeight
+	img := canvas(pngWidth, height)
+	drawText(img, pngPadding, pngPadding, title, foreground)
+
+	max := 0
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+	}
+	maxLabel := strconv.Itoa(max)
+	left := pngPadding + textWidth(maxLabel) + 12
+	top := pngPadding + 2*lineHeight
+	bottom := top + plotHeight
+	plotWidth := pngWidth - left - pngPadding
+
+	drawText(img, pngPadding, top, maxLabel, foreground)
+	drawText(img, pngPadding, bottom-lineHeight, "0", foreground)
+	fill(img, image.Rect(left, top, left+plotWidth, top+1), faded)
+	fill(img, image.Rect(left, bottom, left+plotWidth, bottom+2), foreground)
+
+	if len(values) > 0 {
+		step := plotWidth / len(values)
+		for i, v := range values {
+			if max == 0 || v == 0 {
+				continue
+			}
+			h := v * plotHeight / max
+			x := left + i*step
+			fill(img, image.Rect(x+2, bottom-h, x+step-2, bottom), potato)
+		}
+	}
+
+	first := end.AddDate(0, 0, 1-len(values)).Format("2 Jan")
+	last := end.Format("2 Jan")
+	drawText(img, left, bottom+12, first, foreground)
+	drawText(img, left+plotWidth-textWidth(last), bottom+12, last, foreground)
+	return encode(img)
+}
+
+func canvas(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	fill(img, img.Bounds(), background)
+	return img
+}
+
+func fill(img *image.RGBA, r image.Rectangle, c color.Color) {
+	draw.Draw(img, r, &image.Uniform{c}, image.Point{}, draw.Src)
+}
+
+func textWidth(s string) int {
+	return font.MeasureString(basicfont.Face7x13, s).Ceil() * textScale
+}
+
+// drawText writes s with its top left corner at x, y. The bitmap font is
+// tiny, so it is drawn once and scaled up by textScale.
+func drawText(img *image.RGBA, x, y int, s string, c color.Color) {
+	face := basicfont.Face7x13
+	small := image.NewRGBA(image.Rect(0, 0, font.MeasureString(face, s).Ceil(), 13))
+	d := &font.Drawer{
+		Dst:  small,
+		Src:  &image.Uniform{c},
+		Face: face,
+		Dot:  fixed.P(0, face.Ascent),
+	}
+	d.DrawString(s)
+
+	b := small.Bounds()
+	for sy := b.Min.Y; sy < b.Max.Y; sy++ {
+		for sx := b.Min.X; sx < b.Max.X; sx++ {
+			if _, _, _, a := small.At(sx, sy).RGBA(); a == 0 {
+				continue
+			}
+			fill(img, image.Rect(x+sx*textScale, y+sy*textScale, x+(sx+1)*textScale, y+(sy+1)*textScale), c)
+		}
+	}
+}
+
+func encode(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
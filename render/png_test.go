@@ -0,0 +1,44 @@
+package render
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+	"time"
+)
+
+func TestBarChartPNG(t *testing.T) {
+	data, err := BarChartPNG("Highscore", []string{"kentang", "luq"}, []int{12, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := img.Bounds().Dx(); w != pngWidth {
+		t.Errorf("got width %d", w)
+	}
+	// the longest bar is drawn in the potato color
+	found := false
+	b := img.Bounds()
+	for x := b.Min.X; x < b.Max.X && !found; x++ {
+		for y := b.Min.Y; y < b.Max.Y && !found; y++ {
+			r, g, bl, _ := img.At(x, y).RGBA()
+			found = r>>8 == uint32(potato.R) && g>>8 == uint32(potato.G) && bl>>8 == uint32(potato.B)
+		}
+	}
+	if !found {
+		t.Error("no bar drawn")
+	}
+}
+
+func TestSeriesPNG(t *testing.T) {
+	data, err := SeriesPNG("kentang", []int{0, 1, 5, 0, 2}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := png.Decode(bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,141 @@
+package render
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/luqmanarifin/kentang/model"
+)
+
+// MaxTextLength is the longest text a single LINE message may carry.
+const MaxTextLength = 5000
+
+var (
+	sparks   = []rune("▁▂▃▄▅▆▇█")
+	eighths  = []rune(" ▏▎▍▌▋▊▉█")
+	shades   = []rune("·░▒▓█")
+	weekdays = []string{"M", "T", "W", "T", "F", "S", "S"}
+)
+
+// Bar draws value as a horizontal bar, where max fills width cells.
+func Bar(value, max, width int) string {
+	if max <= 0 || value <= 0 {
+		return ""
+	}
+	n := value * width * 8 / max
+	if n == 0 {
+		n = 1
+	}
+	bar := strings.Repeat("█", n/8)
+	if n%8 > 0 {
+		bar += string(eighths[n%8])
+	}
+	return bar
+}
+
+// Bars draws a bar chart with one labelled row per value.
+func Bars(labels []string, values []int, width int) string {
+	max, pad := 0, 0
+	for i, v := range values {
+		if v > max {
+			max = v
+		}
+		if l := utf8.RuneCountInString(labels[i]); l > pad {
+			pad = l
+		}
+	}
+	var rows []string
+	for i, v := range values {
+		label := labels[i] + strings.Repeat(" ", pad-utf8.RuneCountInString(labels[i]))
+		rows = append(rows, label+" "+Bar(v, max, width)+" "+strconv.Itoa(v))
+	}
+	return strings.Join(rows, "\n")
+}
+
+// Sparkline draws one character per value, scaled to the largest one.
+func Sparkline(values []int) string {
+	max := 0
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+	}
+	var b strings.Builder
+	for _, v := range values {
+		if max == 0 {
+			b.WriteRune(sparks[0])
+			continue
+		}
+		b.WriteRune(sparks[v*(len(sparks)-1)/max])
+	}
+	return b.String()
+}
+
+// Heatmap draws the daily counts of the last weeks up to end, one column per
+// week and one row per weekday, like a GitHub contribution graph.
+func Heatmap(counts map[string]int, end time.Time, weeks int) string {
+	offset := (int(end.Weekday()) + 6) % 7
+	y, m, d := end.Date()
+	start := time.Date(y, m, d-offset-7*(weeks-1), 0, 0, 0, 0, end.Location())
+
+	max := 0
+	for _, c := range counts {
+		if c > max {
+			max = c
+		}
+	}
+
+	var rows []string
+	for wd := 0; wd < 7; wd++ {
+		var b strings.Builder
+		b.WriteString(weekdays[wd] + " ")
+		for w := 0; w < weeks; w++ {
+			day := start.AddDate(0, 0, 7*w+wd)
+			if day.After(end) {
+				break
+			}
+			c := counts[day.Format("2006-01-02")]
+			if c == 0 {
+				b.WriteRune(shades[0])
+				continue
+			}
+			b.WriteRune(shades[(c*(len(shades)-1)+max-1)/max])
+		}
+		rows = append(rows, b.String())
+	}
+	return strings.Join(rows, "\n")
+}
+
+// CountByDay counts entries per local date, keyed as 2006-01-02.
+func CountByDay(entries []model.Entry, loc *time.Location) map[string]int {
+	counts := make(map[string]int)
+	for _, e := range entries {
+		counts[e.Timestamp.In(loc).Format("2006-01-02")]++
+	}
+	return counts
+}
+
+// DailySeries lists the counts of the days days up to and including end.
+func DailySeries(counts map[string]int, end time.Time, days int) []int {
+	series := make([]int, days)
+	for i := range series {
+		series[i] = counts[end.AddDate(0, 0, i-days+1).Format("2006-01-02")]
+	}
+	return series
+}
+
+// Truncate cuts text to at most limit characters, on a line boundary when
+// possible, marking the cut with an ellipsis.
+func Truncate(text string, limit int) string {
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	runes := []rune(text)[:limit-1]
+	cut := string(runes)
+	if i := strings.LastIndex(cut, "\n"); i > 0 {
+		cut = cut[:i+1]
+	}
+	return cut + "…"
+}
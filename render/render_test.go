@@ -0,0 +1,61 @@
+package render
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestBar(t *testing.T) {
+	if got := Bar(10, 10, 4); got != "████" {
+		t.Errorf("full bar: got %q", got)
+	}
+	if got := Bar(5, 10, 3); got != "█▌" {
+		t.Errorf("half bar: got %q", got)
+	}
+	if got := Bar(1, 1000, 4); got != "▏" {
+		t.Errorf("tiny bar: got %q", got)
+	}
+	if got := Bar(0, 10, 4); got != "" {
+		t.Errorf("empty bar: got %q", got)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	if got := Sparkline([]int{0, 1, 7, 0}); got != "▁▂█▁" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestHeatmap(t *testing.T) {
+	end := time.Date(2026, 9, 16, 0, 0, 0, 0, time.UTC) // Wednesday
+	counts := map[string]int{"2026-09-16": 4, "2026-09-07": 1}
+	rows := strings.Split(Heatmap(counts, end, 2), "\n")
+	if len(rows) != 7 {
+		t.Fatalf("got %d rows", len(rows))
+	}
+	if rows[0] != "M ░·" {
+		t.Errorf("monday row: got %q", rows[0])
+	}
+	if rows[2] != "W ·█" {
+		t.Errorf("wednesday row: got %q", rows[2])
+	}
+	if rows[6] != "S ·" {
+		t.Errorf("sunday row stops at end: got %q", rows[6])
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	text := strings.Repeat("kentang koplaq\n", 500)
+	got := Truncate(text, MaxTextLength)
+	if n := utf8.RuneCountInString(got); n > MaxTextLength {
+		t.Errorf("got %d characters", n)
+	}
+	if !strings.HasSuffix(got, "\n…") {
+		t.Errorf("expected cut on a line boundary, got suffix %q", got[len(got)-10:])
+	}
+	if Truncate("short", 10) != "short" {
+		t.Error("short text should be left alone")
+	}
+}
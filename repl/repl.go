@@ -0,0 +1,160 @@
+// Package repl runs the bot in a terminal. Typed lines go through the same
+// commands a chat would, replies are printed, and everything is kept in
+// memory.
+package repl
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"log/slog"
+	"strings"
+
+	"github.com/luqmanarifin/kentang/chat"
+	"github.com/luqmanarifin/kentang/handler"
+	"github.com/luqmanarifin/kentang/service"
+)
+
+const (
+	PlatformREPL = "repl"
+
+	usage = `Type commands like in a chat, e.g. "add telat terlambat" then "telat".
+  :source [id]       chat in another group
+  :user [id] [name]  chat as another user
+  :help              show this
+  :quit              leave`
+)
+
+// Platform prints what the bot would send.
+type Platform struct {
+	out io.Writer
+}
+
+func (p *Platform) Name() string {
+	return PlatformREPL
+}
+
+func (p *Platform) Reply(event *chat.Event, messages ...string) error {
+	for _, message := range messages {
+		fmt.Fprintln(p.out, message)
+	}
+	return nil
+}
+
+func (p *Platform) Push(ctx context.Context, to string, messages ...string) error {
+	for _, message := range messages {
+		fmt.Fprintf(p.out, "[to %s] %s\n", chat.LocalID(to), message)
+	}
+	return nil
+}
+
+// Session is a conversation with the bot, as one user in one group.
+type Session struct {
+	handler  *handler.Handler
+	platform *Platform
+	out      io.Writer
+
+	source string
+	user   string
+	name   string
+}
+
+// NewSession starts a session printing to out, with in-process storage.
+func NewSession(out io.Writer, source, user, name string) *Session {
+	platform := &Platform{out: out}
+	return &Session{
+		handler:  handler.New(service.NewMemory(), service.NewMemoryCache(), platform),
+		platform: platform,
+		out:      out,
+		source:   source,
+		user:     user,
+		name:     name,
+	}
+}
+
+// Send runs one typed line. It returns false once the session is over.
+func (s *Session) Send(line string) bool {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return true
+	}
+	if strings.HasPrefix(line, ":") {
+		return s.meta(strings.Fields(line[1:]))
+	}
+	s.handler.HandleEvent(&chat.Event{
+		Platform: s.platform,
+		Source:   chat.ID(PlatformREPL, s.source),
+		UserID:   chat.ID(PlatformREPL, s.user),
+		UserName: s.name,
+		Text:     line,
+	})
+	return true
+}
+
+func (s *Session) meta(tokens []string) bool {
+	if len(tokens) == 0 {
+		fmt.Fprintln(s.out, usage)
+		return true
+	}
+	switch tokens[0] {
+	case "quit", "q":
+		return false
+	case "source":
+		if len(tokens) == 2 {
+			s.source = tokens[1]
+		}
+		fmt.Fprintln(s.out, "source: "+s.source)
+	case "user":
+		if len(tokens) >= 2 {
+			s.user, s.name = tokens[1], tokens[1]
+		}
+		if len(tokens) > 2 {
+			s.name = strings.Join(tokens[2:], " ")
+		}
+		fmt.Fprintln(s.out, "user: "+s.user+" ("+s.name+")")
+	default:
+		fmt.Fprintln(s.out, usage)
+	}
+	return true
+}
+
+func (s *Session) prompt() string {
+	return s.source + "/" + s.user + "> "
+}
+
+// Main runs `kentang repl` with its command line arguments.
+func Main(args []string, in io.Reader, out io.Writer) error {
+	flags := flag.NewFlagSet("repl", flag.ContinueOnError)
+	source := flags.String("source", "group", "the group to chat in")
+	user := flags.String("user", "me", "the user to chat as")
+	name := flags.String("name", "", "the display name of the user, defaults to the user")
+	verbose := flags.Bool("v", false, "show the bot's logs")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if !*verbose {
+		log.SetOutput(ioutil.Discard)
+		slog.SetDefault(slog.New(slog.NewTextHandler(ioutil.Discard, nil)))
+	}
+	if *name == "" {
+		*name = *user
+	}
+
+	session := NewSession(out, *source, *user, *name)
+	fmt.Fprintln(out, usage)
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(out, session.prompt())
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return scanner.Err()
+		}
+		if !session.Send(scanner.Text()) {
+			return nil
+		}
+	}
+}
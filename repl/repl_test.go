@@ -0,0 +1,39 @@
+package repl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSession(t *testing.T) {
+	var out bytes.Buffer
+	s := NewSession(&out, "group", "niki", "Niki")
+
+	steps := []struct {
+		line string
+		want string
+	}{
+		{"add telat terlambat", "telat has been added"},
+		{"telat", "telat, terlambat lagi?"},
+		{"telat", "telat, terlambat lagi?"},
+		{"list", "1. telat: terlambat (Niki)"},
+		{"highscore", "telat - terlambat : 2"},
+		{":user luq", "user: luq (luq)"},
+		{"remove telat", "Only the creator can remove it"},
+		{":source other", "source: other"},
+		{"list", "No keyword registered."},
+	}
+	for _, step := range steps {
+		out.Reset()
+		if !s.Send(step.line) {
+			t.Fatalf("%q ended the session", step.line)
+		}
+		if !strings.Contains(out.String(), step.want) {
+			t.Errorf("%q: got %q, want it to contain %q", step.line, out.String(), step.want)
+		}
+	}
+	if s.Send(":quit") {
+		t.Error(":quit didn't end the session")
+	}
+}
@@ -0,0 +1,49 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+)
+
+type Period string
+
+const (
+	Daily   Period = "daily"
+	Weekly  Period = "weekly"
+	Monthly Period = "monthly"
+)
+
+func ParsePeriod(s string) (Period, error) {
+	switch p := Period(s); p {
+	case Daily, Weekly, Monthly:
+		return p, nil
+	}
+	return "", fmt.Errorf("Invalid period: %s", s)
+}
+
+// Start returns the beginning of the period containing t, in t's location.
+// Weeks start on Monday.
+func (p Period) Start(t time.Time) time.Time {
+	y, m, d := t.Date()
+	switch p {
+	case Weekly:
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(y, m, d-offset, 0, 0, 0, 0, t.Location())
+	case Monthly:
+		return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	}
+}
+
+// Prev returns the beginning of the period before the one starting at t.
+func (p Period) Prev(t time.Time) time.Time {
+	switch p {
+	case Weekly:
+		return p.Start(t.AddDate(0, 0, -7))
+	case Monthly:
+		return p.Start(t.AddDate(0, -1, 0))
+	default:
+		return p.Start(t.AddDate(0, 0, -1))
+	}
+}
@@ -0,0 +1,195 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/luqmanarifin/kentang/logging"
+	"github.com/luqmanarifin/kentang/model"
+	"github.com/luqmanarifin/kentang/util"
+)
+
+const (
+	StatusSuccess = "success"
+	StatusFailed  = "failed"
+)
+
+// Job runs once per period for every target group, at the start of the
+// period in the group's time zone. A failed run is tried again within the
+// period, waiting longer after each failure. Run's context logs the job and
+// group, and is done when the run takes longer than its lock lasts or the
+// scheduler stops.
+type Job struct {
+	Name    string
+	Period  Period
+	Targets func(ctx context.Context) ([]model.Group, error)
+	Run     func(ctx context.Context, group model.Group, due time.Time) error
+}
+
+// Store keeps the run history, which is how missed runs are detected.
+type Store interface {
+	GetLastJobRun(ctx context.Context, job, source string) (model.JobRun, error)
+	CreateJobRun(ctx context.Context, r *model.JobRun) error
+}
+
+// Locker makes sure only one replica runs a job at a time.
+type Locker interface {
+	AcquireLock(ctx context.Context, key, owner string, ttl time.Duration) (bool, error)
+	ReleaseLock(ctx context.Context, key, owner string) error
+}
+
+type Scheduler struct {
+	jobs     []Job
+	store    Store
+	locker   Locker
+	owner    string
+	interval time.Duration
+	lockTTL  time.Duration
+	// a failed run is retried after backoff, doubled after every further
+	// failure, until it was attempted attempts times
+	backoff  time.Duration
+	attempts int
+	now      func() time.Time
+
+	// ctx is cancelled by Stop, so running jobs give up
+	ctx    context.Context
+	cancel context.CancelFunc
+	stop   chan struct{}
+	wg     sync.WaitGroup
+}
+
+func New(store Store, locker Locker, jobs ...Job) *Scheduler {
+	hostname, _ := os.Hostname()
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		jobs:     jobs,
+		store:    store,
+		locker:   locker,
+		owner:    fmt.Sprintf("%s:%d", hostname, os.Getpid()),
+		interval: time.Minute,
+		lockTTL:  5 * time.Minute,
+		backoff:  5 * time.Minute,
+		attempts: 5,
+		now:      time.Now,
+		ctx:      ctx,
+		cancel:   cancel,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start checks the jobs right away, which catches up runs missed while the
+// process was down, and then once every interval.
+func (s *Scheduler) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			s.Tick()
+			select {
+			case <-ticker.C:
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop cancels the running jobs and waits for them to return. A cancelled
+// run isn't recorded, so it's caught up on the next start.
+func (s *Scheduler) Stop() {
+	close(s.stop)
+	s.cancel()
+	s.wg.Wait()
+}
+
+func (s *Scheduler) Tick() {
+	for _, job := range s.jobs {
+		ctx, cancel := context.WithTimeout(s.ctx, s.lockTTL)
+		groups, err := job.Targets(ctx)
+		cancel()
+		if err != nil {
+			slog.Error("Cannot fetch job targets", "job", job.Name, logging.Err(err))
+			continue
+		}
+		for _, group := range groups {
+			s.runIfDue(job, group)
+		}
+	}
+}
+
+func (s *Scheduler) runIfDue(job Job, group model.Group) {
+	due := job.Period.Start(s.now().In(util.Location(group.Timezone)))
+	// the run must not outlive its lock, or another replica could run it too
+	ctx, cancel := context.WithTimeout(s.ctx, s.lockTTL)
+	defer cancel()
+	ctx = logging.With(ctx, "job", job.Name, "source", group.Source)
+
+	if _, ok := s.isDue(ctx, job, group, due); !ok {
+		return
+	}
+
+	key := fmt.Sprintf("lock:job:%s:%s:%d", job.Name, group.Source, due.Unix())
+	ok, err := s.locker.AcquireLock(ctx, key, s.owner, s.lockTTL)
+	if err != nil || !ok {
+		return
+	}
+	// released even when ctx is done, so the next try doesn't wait for it
+	defer s.locker.ReleaseLock(context.WithoutCancel(ctx), key, s.owner)
+
+	// another replica may have finished it between the check and the lock
+	attempt, ok := s.isDue(ctx, job, group, due)
+	if !ok {
+		return
+	}
+
+	run := &model.JobRun{
+		Job:         job.Name,
+		Source:      group.Source,
+		Owner:       s.owner,
+		Status:      StatusSuccess,
+		Attempt:     attempt,
+		ScheduledAt: due,
+		StartedAt:   s.now(),
+	}
+	if err := job.Run(ctx, group, due); err != nil {
+		if s.ctx.Err() != nil {
+			slog.WarnContext(ctx, "Job cancelled", logging.Err(err))
+			return
+		}
+		slog.ErrorContext(ctx, "Job failed", logging.Err(err))
+		run.Status = StatusFailed
+		run.Error = err.Error()
+	}
+	run.FinishedAt = s.now()
+	if err := s.store.CreateJobRun(ctx, run); err != nil {
+		slog.ErrorContext(ctx, "Cannot record job run", logging.Err(err))
+	}
+}
+
+// isDue tells whether job should run for group's period starting at due,
+// and which attempt at that period it would be.
+func (s *Scheduler) isDue(ctx context.Context, job Job, group model.Group, due time.Time) (int, bool) {
+	last, err := s.store.GetLastJobRun(ctx, job.Name, group.Source)
+	if err != nil {
+		slog.ErrorContext(ctx, "Cannot fetch last job run", logging.Err(err))
+		return 0, false
+	}
+	if last.ID == 0 {
+		// never ran: don't catch up on periods from before the group subscribed
+		return 1, group.SubscribedAt == nil || !group.SubscribedAt.After(due)
+	}
+	if last.ScheduledAt.Before(due) {
+		return 1, true
+	}
+	if last.Status != StatusFailed || last.Attempt >= s.attempts {
+		return 0, false
+	}
+	retryAt := last.FinishedAt.Add(s.backoff << (last.Attempt - 1))
+	return last.Attempt + 1, !s.now().Before(retryAt)
+}
@@ -0,0 +1,206 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/luqmanarifin/kentang/model"
+)
+
+type fakeStore struct {
+	runs []model.JobRun
+}
+
+func (f *fakeStore) GetLastJobRun(ctx context.Context, job, source string) (model.JobRun, error) {
+	var last model.JobRun
+	for _, r := range f.runs {
+		if r.Job == job && r.Source == source && !r.ScheduledAt.Before(last.ScheduledAt) {
+			last = r
+		}
+	}
+	return last, nil
+}
+
+func (f *fakeStore) CreateJobRun(ctx context.Context, r *model.JobRun) error {
+	r.ID = len(f.runs) + 1
+	f.runs = append(f.runs, *r)
+	return nil
+}
+
+type fakeLocker struct {
+	held map[string]string
+}
+
+func (f *fakeLocker) AcquireLock(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	if _, ok := f.held[key]; ok {
+		return false, nil
+	}
+	f.held[key] = owner
+	return true, nil
+}
+
+func (f *fakeLocker) ReleaseLock(ctx context.Context, key, owner string) error {
+	if f.held[key] == owner {
+		delete(f.held, key)
+	}
+	return nil
+}
+
+func TestPeriodStart(t *testing.T) {
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	now := time.Date(2026, 9, 17, 15, 4, 5, 0, loc) // Thursday
+	cases := map[Period]time.Time{
+		Daily:   time.Date(2026, 9, 17, 0, 0, 0, 0, loc),
+		Weekly:  time.Date(2026, 9, 14, 0, 0, 0, 0, loc),
+		Monthly: time.Date(2026, 9, 1, 0, 0, 0, 0, loc),
+	}
+	for p, want := range cases {
+		if got := p.Start(now); !got.Equal(want) {
+			t.Errorf("%s start: got %s, want %s", p, got, want)
+		}
+	}
+	if got := Monthly.Prev(cases[Monthly]); !got.Equal(time.Date(2026, 8, 1, 0, 0, 0, 0, loc)) {
+		t.Errorf("monthly prev: got %s", got)
+	}
+}
+
+func TestSchedulerRunsOncePerPeriod(t *testing.T) {
+	store := &fakeStore{}
+	locker := &fakeLocker{held: make(map[string]string)}
+	group := model.Group{Source: "g", Timezone: "Asia/Jakarta"}
+
+	var calls []time.Time
+	job := Job{
+		Name:    "test",
+		Period:  Daily,
+		Targets: func(ctx context.Context) ([]model.Group, error) { return []model.Group{group}, nil },
+		Run: func(ctx context.Context, g model.Group, due time.Time) error {
+			calls = append(calls, due)
+			return nil
+		},
+	}
+
+	now := time.Date(2026, 9, 17, 10, 0, 0, 0, time.UTC)
+	a := New(store, locker, job)
+	a.now = func() time.Time { return now }
+	b := New(store, locker, job)
+	b.now = a.now
+
+	a.Tick()
+	b.Tick()
+	a.Tick()
+	if len(calls) != 1 {
+		t.Fatalf("expected 1 run, got %d", len(calls))
+	}
+
+	// a restart a day and a half later catches up only the latest period
+	now = now.Add(36 * time.Hour)
+	b.Tick()
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 runs, got %d", len(calls))
+	}
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	if want := time.Date(2026, 9, 19, 0, 0, 0, 0, loc); !calls[1].Equal(want) {
+		t.Errorf("expected catch-up for %s, got %s", want, calls[1])
+	}
+}
+
+func TestSchedulerRetriesFailedRuns(t *testing.T) {
+	store := &fakeStore{}
+	group := model.Group{Source: "g", Timezone: "UTC"}
+
+	calls := 0
+	job := Job{
+		Name:    "test",
+		Period:  Daily,
+		Targets: func(ctx context.Context) ([]model.Group, error) { return []model.Group{group}, nil },
+		Run: func(ctx context.Context, g model.Group, due time.Time) error {
+			calls++
+			return errors.New("push failed")
+		},
+	}
+	now := time.Date(2026, 9, 17, 0, 0, 0, 0, time.UTC)
+	s := New(store, &fakeLocker{held: make(map[string]string)}, job)
+	s.now = func() time.Time { return now }
+
+	s.Tick()
+	s.Tick()
+	if calls != 1 {
+		t.Fatalf("retried before the backoff: %d runs", calls)
+	}
+	for i := 1; i < 24*60; i++ {
+		now = now.Add(time.Minute)
+		s.Tick()
+	}
+	if calls != s.attempts {
+		t.Errorf("got %d runs in the period, want %d", calls, s.attempts)
+	}
+	if last := store.runs[len(store.runs)-1]; last.Attempt != s.attempts || last.Status != StatusFailed {
+		t.Errorf("last run: %+v", last)
+	}
+
+	// the next period starts over
+	now = now.Add(time.Minute)
+	s.Tick()
+	if calls != s.attempts+1 {
+		t.Errorf("next period didn't run: %d runs", calls)
+	}
+}
+
+func TestSchedulerSkipsPeriodsBeforeSubscription(t *testing.T) {
+	now := time.Date(2026, 9, 17, 10, 0, 0, 0, time.UTC)
+	subscribed := now.Add(-time.Hour)
+	group := model.Group{Source: "g", Timezone: "UTC", SubscribedAt: &subscribed, Timestamp: now.Add(-48 * time.Hour)}
+
+	ran := false
+	job := Job{
+		Name:    "test",
+		Period:  Monthly,
+		Targets: func(ctx context.Context) ([]model.Group, error) { return []model.Group{group}, nil },
+		Run: func(ctx context.Context, g model.Group, due time.Time) error {
+			ran = true
+			return nil
+		},
+	}
+	s := New(&fakeStore{}, &fakeLocker{held: make(map[string]string)}, job)
+	s.now = func() time.Time { return now }
+	s.Tick()
+	if ran {
+		t.Error("job ran for a period that started before the group subscribed")
+	}
+}
+
+func TestStopCancelsRunningJob(t *testing.T) {
+	store := &fakeStore{}
+	locker := &fakeLocker{held: make(map[string]string)}
+	group := model.Group{Source: "g"}
+
+	started := make(chan struct{})
+	job := Job{
+		Name:    "slow",
+		Period:  Daily,
+		Targets: func(ctx context.Context) ([]model.Group, error) { return []model.Group{group}, nil },
+		Run: func(ctx context.Context, g model.Group, due time.Time) error {
+			if _, ok := ctx.Deadline(); !ok {
+				t.Error("run has no deadline")
+			}
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+	s := New(store, locker, job)
+	s.now = func() time.Time { return time.Date(2026, 9, 17, 10, 0, 0, 0, time.UTC) }
+	s.Start()
+	<-started
+	s.Stop()
+
+	if len(store.runs) != 0 {
+		t.Errorf("recorded the cancelled run: %+v", store.runs)
+	}
+	if len(locker.held) != 0 {
+		t.Errorf("lock kept after cancelling: %v", locker.held)
+	}
+}
@@ -0,0 +1,94 @@
+-- Creates the tables that don't exist yet, so it can be run again after an
+-- upgrade. Columns added to a table after it was first created are in
+-- migrations/, each run once, in order, after this file.
+
+CREATE TABLE IF NOT EXISTS dictionaries (
+	id INT NOT NULL AUTO_INCREMENT,
+	source VARCHAR(64) NOT NULL,
+	keyword VARCHAR(255) NOT NULL,
+	description VARCHAR(255) NOT NULL,
+	creator VARCHAR(64) NOT NULL,
+	timestamp DATETIME NOT NULL,
+	PRIMARY KEY (id),
+	KEY source_keyword (source, keyword)
+);
+
+CREATE TABLE IF NOT EXISTS entries (
+	id INT NOT NULL AUTO_INCREMENT,
+	source VARCHAR(64) NOT NULL,
+	keyword VARCHAR(255) NOT NULL,
+	timestamp DATETIME NOT NULL,
+	PRIMARY KEY (id),
+	KEY source_timestamp (source, timestamp)
+);
+
+CREATE TABLE IF NOT EXISTS group_settings (
+	source VARCHAR(64) NOT NULL,
+	timezone VARCHAR(64) NOT NULL,
+	recap VARCHAR(16) NOT NULL DEFAULT '',
+	auto_season TINYINT(1) NOT NULL DEFAULT 0,
+	style VARCHAR(16) NOT NULL DEFAULT '',
+	left_at DATETIME NULL DEFAULT NULL,
+	timestamp DATETIME NOT NULL,
+	PRIMARY KEY (source),
+	KEY recap (recap)
+);
+
+CREATE TABLE IF NOT EXISTS job_runs (
+	id INT NOT NULL AUTO_INCREMENT,
+	job VARCHAR(64) NOT NULL,
+	source VARCHAR(64) NOT NULL,
+	owner VARCHAR(255) NOT NULL,
+	status VARCHAR(16) NOT NULL,
+	error TEXT NOT NULL,
+	scheduled_at DATETIME NOT NULL,
+	started_at DATETIME NOT NULL,
+	finished_at DATETIME NOT NULL,
+	PRIMARY KEY (id),
+	KEY job_source_scheduled (job, source, scheduled_at)
+);
+
+CREATE TABLE IF NOT EXISTS seasons (
+	id INT NOT NULL AUTO_INCREMENT,
+	source VARCHAR(64) NOT NULL,
+	name VARCHAR(32) NOT NULL,
+	started_at DATETIME NOT NULL,
+	ended_at DATETIME NOT NULL,
+	PRIMARY KEY (id),
+	UNIQUE KEY source_name (source, name)
+);
+
+CREATE TABLE IF NOT EXISTS standings (
+	id INT NOT NULL AUTO_INCREMENT,
+	season_id INT NOT NULL,
+	source VARCHAR(64) NOT NULL,
+	position INT NOT NULL,
+	keyword VARCHAR(255) NOT NULL,
+	description VARCHAR(255) NOT NULL,
+	count INT NOT NULL,
+	PRIMARY KEY (id),
+	KEY season_position (season_id, position),
+	KEY source_position (source, position)
+);
+
+CREATE TABLE IF NOT EXISTS badges (
+	id INT NOT NULL AUTO_INCREMENT,
+	source VARCHAR(64) NOT NULL,
+	keyword VARCHAR(255) NOT NULL,
+	name VARCHAR(64) NOT NULL,
+	timestamp DATETIME NOT NULL,
+	PRIMARY KEY (id),
+	UNIQUE KEY source_keyword_name (source, keyword, name)
+);
+
+CREATE TABLE IF NOT EXISTS bindings (
+	id INT NOT NULL AUTO_INCREMENT,
+	source VARCHAR(64) NOT NULL,
+	type VARCHAR(16) NOT NULL,
+	`key` VARCHAR(64) NOT NULL,
+	command VARCHAR(255) NOT NULL,
+	creator VARCHAR(64) NOT NULL,
+	timestamp DATETIME NOT NULL,
+	PRIMARY KEY (id),
+	UNIQUE KEY source_type_key (source, type, `key`)
+);
@@ -0,0 +1,100 @@
+// Package server runs the HTTP server with timeouts, optional TLS and a
+// shutdown that lets running requests finish before cleaning up.
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/luqmanarifin/kentang/logging"
+)
+
+type Config struct {
+	Addr string
+
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	// ShutdownTimeout bounds the whole shutdown, cleanups included.
+	ShutdownTimeout time.Duration
+
+	// CertFile and KeyFile serve HTTPS from a certificate on disk.
+	CertFile string
+	KeyFile  string
+}
+
+// TLS tells whether the server speaks HTTPS.
+func (c Config) TLS() bool {
+	return c.CertFile != "" && c.KeyFile != ""
+}
+
+// New makes the server for handler, without starting it.
+func New(c Config, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              c.Addr,
+		Handler:           handler,
+		ReadTimeout:       c.ReadTimeout,
+		ReadHeaderTimeout: c.ReadHeaderTimeout,
+		WriteTimeout:      c.WriteTimeout,
+		IdleTimeout:       c.IdleTimeout,
+	}
+}
+
+// Run listens on c.Addr and serves handler until ctx is done, then shuts
+// down like Serve.
+func Run(ctx context.Context, c Config, handler http.Handler, cleanups ...func(context.Context) error) error {
+	ln, err := net.Listen("tcp", c.Addr)
+	if err != nil {
+		return err
+	}
+	return Serve(ctx, ln, c, handler, cleanups...)
+}
+
+// Serve serves handler on ln until ctx is done. It then stops taking
+// connections, waits for the running requests and runs cleanups in order,
+// all within c.ShutdownTimeout.
+func Serve(ctx context.Context, ln net.Listener, c Config, handler http.Handler, cleanups ...func(context.Context) error) error {
+	s := New(c, handler)
+	errc := make(chan error, 1)
+	go func() {
+		if c.TLS() {
+			slog.Info("Serving HTTPS", "addr", ln.Addr().String())
+			errc <- s.ServeTLS(ln, c.CertFile, c.KeyFile)
+		} else {
+			slog.Info("Serving HTTP", "addr", ln.Addr().String())
+			errc <- s.Serve(ln)
+		}
+	}()
+
+	var serveErr error
+	select {
+	case <-ctx.Done():
+		slog.Info("Shutting down")
+	case serveErr = <-errc:
+		slog.Error("Server stopped", logging.Err(serveErr))
+	}
+
+	shutdownCtx := context.Background()
+	if c.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		shutdownCtx, cancel = context.WithTimeout(shutdownCtx, c.ShutdownTimeout)
+		defer cancel()
+	}
+	if err := s.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("Requests left running", logging.Err(err))
+	}
+	for _, cleanup := range cleanups {
+		if err := cleanup(shutdownCtx); err != nil {
+			slog.Error("Cannot shut down cleanly", logging.Err(err))
+		}
+	}
+	if errors.Is(serveErr, http.ErrServerClosed) {
+		return nil
+	}
+	return serveErr
+}
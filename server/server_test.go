@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestServeFinishesRequestsOnShutdown(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte("done"))
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var order []string
+	served := make(chan error)
+	go func() {
+		served <- Serve(ctx, ln, Config{ShutdownTimeout: time.Second}, handler,
+			func(context.Context) error { order = append(order, "drain"); return nil },
+			func(context.Context) error { order = append(order, "close"); return nil },
+		)
+	}()
+
+	body := make(chan string)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(resp.Body)
+		body <- string(b)
+	}()
+	<-started
+	cancel()
+
+	if got := <-body; got != "done" {
+		t.Errorf("in-flight request got %q", got)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("Serve returned %v", err)
+	}
+	if len(order) != 2 || order[0] != "drain" || order[1] != "close" {
+		t.Errorf("cleanups ran as %v", order)
+	}
+}
+
+func TestNew(t *testing.T) {
+	c := Config{Addr: ":8080", ReadTimeout: time.Second, WriteTimeout: 2 * time.Second, IdleTimeout: 3 * time.Second}
+	s := New(c, http.NotFoundHandler())
+	if s.ReadTimeout != time.Second || s.WriteTimeout != 2*time.Second || s.IdleTimeout != 3*time.Second {
+		t.Errorf("got timeouts %v %v %v", s.ReadTimeout, s.WriteTimeout, s.IdleTimeout)
+	}
+	if c.TLS() || s.TLSConfig != nil {
+		t.Error("got TLS without certificates")
+	}
+	if !(Config{CertFile: "cert.pem", KeyFile: "key.pem"}).TLS() {
+		t.Error("got no TLS with certificate files")
+	}
+}
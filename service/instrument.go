@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/luqmanarifin/kentang/metrics"
+	"github.com/luqmanarifin/kentang/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// timedDB times every query it runs in metrics.MySQLQueries, and traces it
+// in a span.
+type timedDB struct {
+	*sql.DB
+}
+
+func (db timedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, done := startQuery(ctx, query)
+	res, err := db.DB.ExecContext(ctx, query, args...)
+	done(err)
+	return res, err
+}
+
+// QueryContext times until the first rows are ready, not the reading of all
+// of them.
+func (db timedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, done := startQuery(ctx, query)
+	rows, err := db.DB.QueryContext(ctx, query, args...)
+	done(err)
+	return rows, err
+}
+
+func (db timedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, done := startQuery(ctx, query)
+	row := db.DB.QueryRowContext(ctx, query, args...)
+	done(row.Err())
+	return row
+}
+
+// startQuery starts timing query, until done is called with its result. The
+// statement is traced as is, the values stay out of it.
+func startQuery(ctx context.Context, query string) (context.Context, func(error)) {
+	op := queryOp(query)
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, "mysql "+op,
+		attribute.String("db.system", "mysql"),
+		attribute.String("db.statement", strings.Join(strings.Fields(query), " ")))
+	return ctx, func(err error) {
+		if err == sql.ErrNoRows {
+			err = nil
+		}
+		metrics.MySQLQueries.WithLabelValues(op, metrics.Status(err)).Observe(time.Since(start).Seconds())
+		tracing.End(span, err)
+	}
+}
+
+// queryOp names a query by its statement and first table, e.g.
+// "select entries".
+func queryOp(query string) string {
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		return "unknown"
+	}
+	verb := words[0]
+	after := map[string]string{"select": "from", "delete": "from", "insert": "into", "replace": "into", "update": "update"}[verb]
+	for i, word := range words {
+		if word == after && i+1 < len(words) {
+			table := strings.FieldsFunc(words[i+1], func(r rune) bool { return r == '(' || r == ',' || r == '`' })
+			if len(table) > 0 {
+				return verb + " " + table[0]
+			}
+		}
+	}
+	return verb
+}
+
+type commandStartKey struct{}
+
+// timeCommands is a hook timing every command in metrics.RedisCommands and
+// tracing it in a span.
+type timeCommands struct{}
+
+func (timeCommands) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	ctx, _ = tracing.Start(ctx, "redis "+strings.ToLower(cmd.Name()), attribute.String("db.system", "redis"))
+	return context.WithValue(ctx, commandStartKey{}, time.Now()), nil
+}
+
+func (timeCommands) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	start, ok := ctx.Value(commandStartKey{}).(time.Time)
+	if !ok {
+		return nil
+	}
+	err := cmd.Err()
+	if err == redis.Nil {
+		err = nil
+	}
+	metrics.RedisCommands.WithLabelValues(strings.ToLower(cmd.Name()), metrics.Status(err)).Observe(time.Since(start).Seconds())
+	tracing.End(trace.SpanFromContext(ctx), err)
+	return nil
+}
+
+func (timeCommands) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (timeCommands) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	return nil
+}
@@ -0,0 +1,18 @@
+package service
+
+import "testing"
+
+func TestQueryOp(t *testing.T) {
+	for query, want := range map[string]string{
+		"SELECT id, source FROM dictionaries WHERE source = ?":              "select dictionaries",
+		"\n\t\tSELECT keyword, COUNT(*) FROM entries\n\t\tWHERE source = ?": "select entries",
+		"INSERT INTO entries(source, keyword) VALUES(?, ?)":                 "insert entries",
+		"DELETE FROM group_settings WHERE source=?":                         "delete group_settings",
+		"UPDATE `seasons` SET ended_at = ?":                                 "update seasons",
+		"":                                                                  "unknown",
+	} {
+		if got := queryOp(query); got != want {
+			t.Errorf("queryOp(%q) = %q, want %q", query, got, want)
+		}
+	}
+}
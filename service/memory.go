@@ -0,0 +1,586 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/luqmanarifin/kentang/model"
+	"github.com/luqmanarifin/kentang/util"
+)
+
+// Memory is a Store keeping everything in process, for trying the bot
+// without a database. It mirrors what the MySQL queries return, including
+// sql.ErrNoRows for missing rows.
+type Memory struct {
+	mu sync.Mutex
+
+	lastID       int
+	dictionaries []model.Dictionary
+	entries      []model.Entry
+	groups       map[string]model.Group
+	jobRuns      []model.JobRun
+	badges       []model.Badge
+	bindings     []model.Binding
+	seasons      []model.Season
+	standings    []model.Standing
+}
+
+func NewMemory() *Memory {
+	return &Memory{groups: make(map[string]model.Group)}
+}
+
+func (m *Memory) nextID() int {
+	m.lastID++
+	return m.lastID
+}
+
+func (m *Memory) GetSources(ctx context.Context) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	seen := make(map[string]bool)
+	for _, d := range m.dictionaries {
+		seen[d.Source] = true
+	}
+	for _, e := range m.entries {
+		seen[e.Source] = true
+	}
+	for source := range m.groups {
+		seen[source] = true
+	}
+	var sources []string
+	for source := range seen {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	return sources, nil
+}
+
+func (m *Memory) CreateDictionary(ctx context.Context, d *model.Dictionary) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d.ID = m.nextID()
+	d.Timestamp = time.Now()
+	m.dictionaries = append(m.dictionaries, *d)
+	return nil
+}
+
+func (m *Memory) RemoveDictionaryBySource(ctx context.Context, source string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dictionaries = filterDictionaries(m.dictionaries, func(d model.Dictionary) bool {
+		return d.Source != source
+	})
+	return nil
+}
+
+func (m *Memory) RemoveDictionary(ctx context.Context, d *model.Dictionary) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dictionaries = filterDictionaries(m.dictionaries, func(o model.Dictionary) bool {
+		return o.Source != d.Source || o.Keyword != d.Keyword || o.Target != d.Target
+	})
+	return nil
+}
+
+func (m *Memory) UpdateDictionary(ctx context.Context, d *model.Dictionary) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.dictionaries {
+		if m.dictionaries[i].ID == d.ID {
+			m.dictionaries[i].Description = d.Description
+		}
+	}
+	return nil
+}
+
+func (m *Memory) GetDictionary(ctx context.Context, id int) (model.Dictionary, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range m.dictionaries {
+		if d.ID == id {
+			return d, nil
+		}
+	}
+	return model.Dictionary{}, sql.ErrNoRows
+}
+
+func (m *Memory) GetDictionaryByKeyword(ctx context.Context, source, keyword string) (model.Dictionary, error) {
+	return m.GetDictionaryByTarget(ctx, source, keyword, "")
+}
+
+func (m *Memory) GetDictionaryByTarget(ctx context.Context, source, keyword, target string) (model.Dictionary, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range m.dictionaries {
+		if d.Source == source && d.Keyword == keyword && d.Target == target {
+			return d, nil
+		}
+	}
+	return model.Dictionary{}, sql.ErrNoRows
+}
+
+func (m *Memory) GetAllDictionaries(ctx context.Context, source string) ([]model.Dictionary, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return filterDictionaries(m.dictionaries, func(d model.Dictionary) bool {
+		return d.Source == source
+	}), nil
+}
+
+func (m *Memory) CreateEntry(ctx context.Context, entry *model.Entry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if entry.EventID != "" {
+		for _, e := range m.entries {
+			if e.EventID == entry.EventID {
+				return ErrDuplicate
+			}
+		}
+	}
+	entry.ID = m.nextID()
+	entry.Timestamp = time.Now()
+	m.entries = append(m.entries, *entry)
+	return nil
+}
+
+func (m *Memory) RemoveEntryByKeyword(ctx context.Context, source, keyword string) error {
+	return m.RemoveEntryByTarget(ctx, source, keyword, "")
+}
+
+func (m *Memory) RemoveEntryByTarget(ctx context.Context, source, keyword, target string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = filterEntries(m.entries, func(e model.Entry) bool {
+		return e.Source != source || e.Keyword != keyword || e.Target != target
+	})
+	return nil
+}
+
+func (m *Memory) RemoveEntryBySource(ctx context.Context, source string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = filterEntries(m.entries, func(e model.Entry) bool {
+		return e.Source != source
+	})
+	return nil
+}
+
+func (m *Memory) GetAllEntries(ctx context.Context, source string) ([]model.Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return filterEntries(m.entries, func(e model.Entry) bool {
+		return e.Source == source
+	}), nil
+}
+
+// getEntriesByDay matches DATEDIFF, which counts calendar days.
+func (m *Memory) getEntriesByDay(source string, day int) ([]model.Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	today := dayNumber(time.Now())
+	return filterEntries(m.entries, func(e model.Entry) bool {
+		return e.Source == source && today-dayNumber(e.Timestamp) <= day
+	}), nil
+}
+
+func dayNumber(t time.Time) int {
+	y, mo, d := t.Date()
+	return int(time.Date(y, mo, d, 0, 0, 0, 0, time.UTC).Unix() / 86400)
+}
+
+func (m *Memory) GetMonthEntries(ctx context.Context, source string) ([]model.Entry, error) {
+	return m.getEntriesByDay(source, 30)
+}
+
+func (m *Memory) GetWeekEntries(ctx context.Context, source string) ([]model.Entry, error) {
+	return m.getEntriesByDay(source, 7)
+}
+
+func (m *Memory) GetDayEntries(ctx context.Context, source string) ([]model.Entry, error) {
+	return m.getEntriesByDay(source, 1)
+}
+
+func (m *Memory) GetEntriesBetween(ctx context.Context, source string, from, to time.Time) ([]model.Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return filterEntries(m.entries, func(e model.Entry) bool {
+		return e.Source == source && !e.Timestamp.Before(from) && e.Timestamp.Before(to)
+	}), nil
+}
+
+func (m *Memory) GetEntriesByKeyword(ctx context.Context, source, keyword string) ([]model.Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return filterEntries(m.entries, func(e model.Entry) bool {
+		return e.Source == source && e.Keyword == keyword && e.Target == ""
+	}), nil
+}
+
+func (m *Memory) GetEntriesByKeywordSince(ctx context.Context, source, keyword string, from time.Time) ([]model.Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return filterEntries(m.entries, func(e model.Entry) bool {
+		return e.Source == source && e.Keyword == keyword && e.Target == "" && !e.Timestamp.Before(from)
+	}), nil
+}
+
+func (m *Memory) CountEntriesByKeyword(ctx context.Context, source, keyword string) (int, error) {
+	es, _ := m.GetEntriesByKeyword(ctx, source, keyword)
+	return len(es), nil
+}
+
+func (m *Memory) GetEntries(ctx context.Context, f EntryFilter) ([]model.Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	es := filterEntries(m.entries, func(e model.Entry) bool {
+		return e.Source == f.Source && e.ID > f.AfterID &&
+			(f.Keyword == "" || e.Keyword == f.Keyword) &&
+			(f.Target == "" || e.Target == f.Target) &&
+			(f.From.IsZero() || !e.Timestamp.Before(f.From)) &&
+			(f.To.IsZero() || e.Timestamp.Before(f.To))
+	})
+	if len(es) > f.Limit {
+		es = es[:f.Limit]
+	}
+	return es, nil
+}
+
+func (m *Memory) GetGroup(ctx context.Context, source string) (model.Group, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	g, ok := m.groups[source]
+	if !ok {
+		return model.Group{Source: source, Timezone: util.DEFAULT_TIMEZONE}, nil
+	}
+	return g, nil
+}
+
+func (m *Memory) SaveGroup(ctx context.Context, g *model.Group) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	g.Timestamp = time.Now()
+	m.groups[g.Source] = *g
+	return nil
+}
+
+func (m *Memory) getGroupsWhere(cond func(g model.Group) bool) ([]model.Group, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var gs []model.Group
+	for _, g := range m.groups {
+		if cond(g) {
+			gs = append(gs, g)
+		}
+	}
+	sort.Slice(gs, func(i, j int) bool { return gs[i].Source < gs[j].Source })
+	return gs, nil
+}
+
+func (m *Memory) GetGroupsByRecap(ctx context.Context, recap string) ([]model.Group, error) {
+	return m.getGroupsWhere(func(g model.Group) bool {
+		return g.Recap == recap && g.LeftAt == nil
+	})
+}
+
+func (m *Memory) GetAutoSeasonGroups(ctx context.Context) ([]model.Group, error) {
+	return m.getGroupsWhere(func(g model.Group) bool {
+		return g.AutoSeason && g.LeftAt == nil
+	})
+}
+
+func (m *Memory) GetGroupsLeftBefore(ctx context.Context, t time.Time) ([]model.Group, error) {
+	return m.getGroupsWhere(func(g model.Group) bool {
+		return g.LeftAt != nil && g.LeftAt.Before(t)
+	})
+}
+
+func (m *Memory) RemoveGroup(ctx context.Context, source string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.groups, source)
+	return nil
+}
+
+func (m *Memory) CreateJobRun(ctx context.Context, r *model.JobRun) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r.ID = m.nextID()
+	m.jobRuns = append(m.jobRuns, *r)
+	return nil
+}
+
+func (m *Memory) GetLastJobRun(ctx context.Context, job, source string) (model.JobRun, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var last model.JobRun
+	for _, r := range m.jobRuns {
+		if r.Job == job && r.Source == source && !r.ScheduledAt.Before(last.ScheduledAt) {
+			last = r
+		}
+	}
+	return last, nil
+}
+
+func (m *Memory) CreateBadge(ctx context.Context, b *model.Badge) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, o := range m.badges {
+		if o.Source == b.Source && o.Keyword == b.Keyword && o.Name == b.Name {
+			return nil
+		}
+	}
+	b.ID = m.nextID()
+	b.Timestamp = time.Now()
+	m.badges = append(m.badges, *b)
+	return nil
+}
+
+func (m *Memory) GetBadges(ctx context.Context, source, keyword string) ([]model.Badge, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var bs []model.Badge
+	for _, b := range m.badges {
+		if b.Source == source && b.Keyword == keyword {
+			bs = append(bs, b)
+		}
+	}
+	return bs, nil
+}
+
+func (m *Memory) removeBadges(keep func(b model.Badge) bool) {
+	var bs []model.Badge
+	for _, b := range m.badges {
+		if keep(b) {
+			bs = append(bs, b)
+		}
+	}
+	m.badges = bs
+}
+
+func (m *Memory) RemoveBadgeByKeyword(ctx context.Context, source, keyword string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.removeBadges(func(b model.Badge) bool {
+		return b.Source != source || b.Keyword != keyword
+	})
+	return nil
+}
+
+func (m *Memory) RemoveBadgeBySource(ctx context.Context, source string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.removeBadges(func(b model.Badge) bool {
+		return b.Source != source
+	})
+	return nil
+}
+
+func (m *Memory) SaveBinding(ctx context.Context, b *model.Binding) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b.Timestamp = time.Now()
+	for i, o := range m.bindings {
+		if o.Source == b.Source && o.Type == b.Type && o.Key == b.Key {
+			b.ID = o.ID
+			m.bindings[i] = *b
+			return nil
+		}
+	}
+	b.ID = m.nextID()
+	m.bindings = append(m.bindings, *b)
+	return nil
+}
+
+func (m *Memory) GetBinding(ctx context.Context, source, typ, key string) (model.Binding, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, b := range m.bindings {
+		if b.Source == source && b.Type == typ && b.Key == key {
+			return b, nil
+		}
+	}
+	return model.Binding{}, sql.ErrNoRows
+}
+
+func (m *Memory) GetAllBindings(ctx context.Context, source string) ([]model.Binding, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var bs []model.Binding
+	for _, b := range m.bindings {
+		if b.Source == source {
+			bs = append(bs, b)
+		}
+	}
+	sort.SliceStable(bs, func(i, j int) bool {
+		if bs[i].Type != bs[j].Type {
+			return bs[i].Type < bs[j].Type
+		}
+		return bs[i].Command < bs[j].Command
+	})
+	return bs, nil
+}
+
+func (m *Memory) removeBindings(keep func(b model.Binding) bool) {
+	var bs []model.Binding
+	for _, b := range m.bindings {
+		if keep(b) {
+			bs = append(bs, b)
+		}
+	}
+	m.bindings = bs
+}
+
+func (m *Memory) RemoveBinding(ctx context.Context, source, typ, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.removeBindings(func(b model.Binding) bool {
+		return b.Source != source || b.Type != typ || b.Key != key
+	})
+	return nil
+}
+
+func (m *Memory) RemoveBindingByCommand(ctx context.Context, source, typ, command string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.removeBindings(func(b model.Binding) bool {
+		return b.Source != source || b.Type != typ || b.Command != command
+	})
+	return nil
+}
+
+func (m *Memory) RemoveBindingBySource(ctx context.Context, source string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.removeBindings(func(b model.Binding) bool {
+		return b.Source != source
+	})
+	return nil
+}
+
+func (m *Memory) CreateSeason(ctx context.Context, s *model.Season, standings []model.Standing) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, o := range m.seasons {
+		if o.Source == s.Source && o.Name == s.Name {
+			return ErrDuplicate
+		}
+	}
+	s.ID = m.nextID()
+	m.seasons = append(m.seasons, *s)
+	for _, st := range standings {
+		st.ID = m.nextID()
+		st.SeasonID = s.ID
+		st.Source = s.Source
+		m.standings = append(m.standings, st)
+	}
+	return nil
+}
+
+func (m *Memory) GetLastSeason(ctx context.Context, source string) (model.Season, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var last model.Season
+	for _, s := range m.seasons {
+		if s.Source == source && !s.EndedAt.Before(last.EndedAt) {
+			last = s
+		}
+	}
+	return last, nil
+}
+
+func (m *Memory) GetSeasonByName(ctx context.Context, source, name string) (model.Season, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, s := range m.seasons {
+		if s.Source == source && s.Name == name {
+			return s, nil
+		}
+	}
+	return model.Season{}, sql.ErrNoRows
+}
+
+func (m *Memory) GetSeasons(ctx context.Context, source string) ([]model.Season, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var ss []model.Season
+	for _, s := range m.seasons {
+		if s.Source == source {
+			ss = append(ss, s)
+		}
+	}
+	sort.SliceStable(ss, func(i, j int) bool { return ss[i].EndedAt.Before(ss[j].EndedAt) })
+	return ss, nil
+}
+
+func (m *Memory) getStandingsWhere(cond func(st model.Standing) bool) ([]model.Standing, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var sts []model.Standing
+	for _, st := range m.standings {
+		if cond(st) {
+			sts = append(sts, st)
+		}
+	}
+	sort.SliceStable(sts, func(i, j int) bool {
+		if sts[i].SeasonID != sts[j].SeasonID {
+			return sts[i].SeasonID < sts[j].SeasonID
+		}
+		return sts[i].Position < sts[j].Position
+	})
+	return sts, nil
+}
+
+func (m *Memory) GetStandings(ctx context.Context, seasonID int) ([]model.Standing, error) {
+	return m.getStandingsWhere(func(st model.Standing) bool {
+		return st.SeasonID == seasonID
+	})
+}
+
+func (m *Memory) GetChampions(ctx context.Context, source string) ([]model.Standing, error) {
+	return m.getStandingsWhere(func(st model.Standing) bool {
+		return st.Source == source && st.Position == 1
+	})
+}
+
+func (m *Memory) RemoveSeasonBySource(ctx context.Context, source string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var sts []model.Standing
+	for _, st := range m.standings {
+		if st.Source != source {
+			sts = append(sts, st)
+		}
+	}
+	m.standings = sts
+	var ss []model.Season
+	for _, s := range m.seasons {
+		if s.Source != source {
+			ss = append(ss, s)
+		}
+	}
+	m.seasons = ss
+	return nil
+}
+
+func filterDictionaries(ds []model.Dictionary, keep func(d model.Dictionary) bool) []model.Dictionary {
+	var kept []model.Dictionary
+	for _, d := range ds {
+		if keep(d) {
+			kept = append(kept, d)
+		}
+	}
+	return kept
+}
+
+func filterEntries(es []model.Entry, keep func(e model.Entry) bool) []model.Entry {
+	var kept []model.Entry
+	for _, e := range es {
+		if keep(e) {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
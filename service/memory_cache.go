@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/luqmanarifin/kentang/util"
+)
+
+// ErrCacheMiss is returned by MemoryCache for missing keys, like redis.Nil.
+var ErrCacheMiss = errors.New("cache miss")
+
+type cacheItem struct {
+	value   []byte
+	expires time.Time
+}
+
+// MemoryCache is a Cache keeping everything in process, using the same keys
+// as Redis.
+type MemoryCache struct {
+	mu    sync.Mutex
+	items map[string]cacheItem
+}
+
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{items: make(map[string]cacheItem)}
+}
+
+func (c *MemoryCache) get(key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item, ok := c.items[key]
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	if !item.expires.IsZero() && time.Now().After(item.expires) {
+		delete(c.items, key)
+		return nil, ErrCacheMiss
+	}
+	return item.value, nil
+}
+
+// set stores value under key, forever when ttl is 0.
+func (c *MemoryCache) set(key string, value []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item := cacheItem{value: value}
+	if ttl > 0 {
+		item.expires = time.Now().Add(ttl)
+	}
+	c.items[key] = item
+}
+
+func (c *MemoryCache) del(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		delete(c.items, key)
+	}
+}
+
+func (c *MemoryCache) getString(key string) (string, error) {
+	value, err := c.get(key)
+	return string(value), err
+}
+
+func (c *MemoryCache) GetKeyword(ctx context.Context, source, keyword string) (string, error) {
+	return c.getString(source + ":" + keyword)
+}
+
+func (c *MemoryCache) AddKeyword(ctx context.Context, source, keyword, val string) error {
+	c.set(source+":"+keyword, []byte(val), 0)
+	return nil
+}
+
+func (c *MemoryCache) RemoveKeyword(ctx context.Context, source, keyword string) error {
+	return c.AddKeyword(ctx, source, keyword, util.NOT_EXIST)
+}
+
+func (c *MemoryCache) RemoveAllKeyword(ctx context.Context, source string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.items {
+		if strings.HasPrefix(key, source+":") {
+			delete(c.items, key)
+		}
+	}
+	return nil
+}
+
+func (c *MemoryCache) GetDisplayName(ctx context.Context, userId string) (string, error) {
+	return c.getString(userId)
+}
+
+func (c *MemoryCache) SetDisplayName(ctx context.Context, userId, name string) error {
+	c.set(userId, []byte(name), 10*24*time.Hour)
+	return nil
+}
+
+func (c *MemoryCache) GetPictureURL(ctx context.Context, userId string) (string, error) {
+	return c.getString("picture:" + userId)
+}
+
+func (c *MemoryCache) SetPictureURL(ctx context.Context, userId, url string) error {
+	c.set("picture:"+userId, []byte(url), 10*24*time.Hour)
+	return nil
+}
+
+func (c *MemoryCache) RemoveProfile(ctx context.Context, userId string) error {
+	c.del(userId, "picture:"+userId)
+	return nil
+}
+
+func (c *MemoryCache) MarkEvent(ctx context.Context, id string, ttl time.Duration) (bool, error) {
+	return c.AcquireLock(ctx, "event:"+id, "1", ttl)
+}
+
+func (c *MemoryCache) EventMarked(ctx context.Context, id string) (bool, error) {
+	_, err := c.get("event:" + id)
+	return err == nil, nil
+}
+
+func (c *MemoryCache) UseNonce(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	return c.AcquireLock(ctx, "nonce:"+nonce, "1", ttl)
+}
+
+func (c *MemoryCache) AcquireLock(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if item, ok := c.items[key]; ok && time.Now().Before(item.expires) {
+		return false, nil
+	}
+	c.items[key] = cacheItem{value: []byte(owner), expires: time.Now().Add(ttl)}
+	return true, nil
+}
+
+func (c *MemoryCache) ReleaseLock(ctx context.Context, key, owner string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if item, ok := c.items[key]; ok && string(item.value) == owner {
+		delete(c.items, key)
+	}
+	return nil
+}
+
+func (c *MemoryCache) SetChart(ctx context.Context, id string, png []byte, ttl time.Duration) error {
+	c.set("chart:"+id, png, ttl)
+	return nil
+}
+
+func (c *MemoryCache) GetChart(ctx context.Context, id string) ([]byte, error) {
+	return c.get("chart:" + id)
+}
+
+func (c *MemoryCache) SetPending(ctx context.Context, source, userId, kind, val string, ttl time.Duration) error {
+	c.set("pending:"+kind+":"+source+":"+userId, []byte(val), ttl)
+	return nil
+}
+
+func (c *MemoryCache) TakePending(ctx context.Context, source, userId, kind string) (string, error) {
+	key := "pending:" + kind + ":" + source + ":" + userId
+	val, err := c.getString(key)
+	if err != nil {
+		return "", err
+	}
+	c.del(key)
+	return val, nil
+}
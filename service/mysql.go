@@ -1,16 +1,22 @@
 package service
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/luqmanarifin/kentang/model"
+	"github.com/luqmanarifin/kentang/util"
 )
 
+// errDuplicateKey is MySQL's ER_DUP_ENTRY.
+const errDuplicateKey = 1062
+
 type MySQL struct {
-	db *sql.DB
+	db timedDB
 }
 
 // Option holds all necessary options for database.
@@ -23,51 +29,105 @@ type MySQLOption struct {
 	Charset  string
 }
 
+// String tells where opt connects to, without the password.
+func (opt MySQLOption) String() string {
+	return fmt.Sprintf("%s@%s:%s/%s", opt.User, opt.Host, opt.Port, opt.Database)
+}
+
 // NewMySQL returns a pointer of MySQL instance and error.
 func NewMySQL(opt MySQLOption) (*MySQL, error) {
-	db, _ := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=%s", opt.User, opt.Password, opt.Host, opt.Port, opt.Database, opt.Charset))
+	db, _ := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=%s&parseTime=true", opt.User, opt.Password, opt.Host, opt.Port, opt.Database, opt.Charset))
 	err := db.Ping()
 	if err != nil {
 		return &MySQL{}, err
 	}
-	log.Printf("Success connecting to %s:%s with credential %s:%s\n", opt.Host, opt.Port, opt.User, opt.Password)
-	return &MySQL{db: db}, nil
+	slog.Info("Connected to MySQL", "mysql", opt.String())
+	return &MySQL{db: timedDB{db}}, nil
+}
+
+// Ping checks that MySQL answers.
+func (m *MySQL) Ping(ctx context.Context) error {
+	return m.db.PingContext(ctx)
+}
+
+func (m *MySQL) Close() error {
+	return m.db.Close()
+}
+
+// GetSources returns every source the bot keeps anything for.
+func (m *MySQL) GetSources(ctx context.Context) ([]string, error) {
+	var sources []string
+
+	rows, err := m.db.QueryContext(ctx, `
+			SELECT source FROM dictionaries
+			UNION SELECT source FROM entries
+			UNION SELECT source FROM group_settings
+			ORDER BY source
+	`)
+	if err != nil {
+		return sources, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var source string
+
+		if err = rows.Scan(&source); err != nil {
+			return sources, err
+		}
+
+		sources = append(sources, source)
+	}
+
+	return sources, nil
 }
 
-func (m *MySQL) CreateDictionary(d *model.Dictionary) error {
-	_, err := m.db.Exec("INSERT INTO dictionaries(source, keyword, description, creator, timestamp) VALUES(?, ?, ?, ?, ?)",
-		d.Source, d.Keyword, d.Description, d.Creator, time.Now())
+func (m *MySQL) CreateDictionary(ctx context.Context, d *model.Dictionary) error {
+	_, err := m.db.ExecContext(ctx, "INSERT INTO dictionaries(source, keyword, description, creator, target, timestamp) VALUES(?, ?, ?, ?, ?, ?)",
+		d.Source, d.Keyword, d.Description, d.Creator, d.Target, time.Now())
 	return err
 }
 
-func (m *MySQL) RemoveDictionaryBySource(source string) error {
-	_, err := m.db.Exec("DELETE FROM dictionaries WHERE source=?",
+func (m *MySQL) RemoveDictionaryBySource(ctx context.Context, source string) error {
+	_, err := m.db.ExecContext(ctx, "DELETE FROM dictionaries WHERE source=?",
 		source)
 	return err
 }
 
-func (m *MySQL) RemoveDictionary(d *model.Dictionary) error {
-	_, err := m.db.Exec("DELETE FROM dictionaries WHERE source=? AND keyword=?",
-		d.Source, d.Keyword)
+func (m *MySQL) RemoveDictionary(ctx context.Context, d *model.Dictionary) error {
+	_, err := m.db.ExecContext(ctx, "DELETE FROM dictionaries WHERE source=? AND keyword=? AND target=?",
+		d.Source, d.Keyword, d.Target)
 	return err
 }
 
-func (m *MySQL) GetDictionary(id int) (*model.Dictionary, error) {
-	var d *model.Dictionary
+// UpdateDictionary saves the description of the dictionary with d's ID.
+func (m *MySQL) UpdateDictionary(ctx context.Context, d *model.Dictionary) error {
+	_, err := m.db.ExecContext(ctx, "UPDATE dictionaries SET description=? WHERE id=?",
+		d.Description, d.ID)
+	return err
+}
 
-	s := "SELECT id, source, keyword, description, creator FROM dictionaries WHERE id = ?"
-	err := m.db.QueryRow(s, id).Scan(d.ID, d.Source, d.Keyword, d.Description, d.Creator)
+func (m *MySQL) GetDictionary(ctx context.Context, id int) (model.Dictionary, error) {
+	var d model.Dictionary
+
+	err := m.db.QueryRowContext(ctx, "SELECT id, source, keyword, description, creator, target, timestamp FROM dictionaries WHERE id = ?", id).Scan(&d.ID, &d.Source, &d.Keyword, &d.Description, &d.Creator, &d.Target, &d.Timestamp)
 	if err != nil {
-		return &model.Dictionary{}, err
+		return model.Dictionary{}, err
 	}
 
 	return d, nil
 }
 
-func (m *MySQL) GetDictionaryByKeyword(source, keyword string) (model.Dictionary, error) {
+func (m *MySQL) GetDictionaryByKeyword(ctx context.Context, source, keyword string) (model.Dictionary, error) {
+	return m.GetDictionaryByTarget(ctx, source, keyword, "")
+}
+
+// GetDictionaryByTarget returns a keyword attached to a user, or a plain one
+// when target is empty.
+func (m *MySQL) GetDictionaryByTarget(ctx context.Context, source, keyword, target string) (model.Dictionary, error) {
 	var d model.Dictionary
 
-	err := m.db.QueryRow("SELECT id, source, keyword, description, creator FROM dictionaries WHERE source = ? AND keyword = ?", source, keyword).Scan(&d.ID, &d.Source, &d.Keyword, &d.Description, &d.Creator)
+	err := m.db.QueryRowContext(ctx, "SELECT id, source, keyword, description, creator, target FROM dictionaries WHERE source = ? AND keyword = ? AND target = ?", source, keyword, target).Scan(&d.ID, &d.Source, &d.Keyword, &d.Description, &d.Creator, &d.Target)
 	if err != nil {
 		return model.Dictionary{}, err
 	}
@@ -75,11 +135,11 @@ func (m *MySQL) GetDictionaryByKeyword(source, keyword string) (model.Dictionary
 	return d, nil
 }
 
-func (m *MySQL) GetAllDictionaries(source string) ([]model.Dictionary, error) {
+func (m *MySQL) GetAllDictionaries(ctx context.Context, source string) ([]model.Dictionary, error) {
 	var ds []model.Dictionary
 
-	rows, err := m.db.Query(`
-			SELECT id, source, keyword, description, creator
+	rows, err := m.db.QueryContext(ctx, `
+			SELECT id, source, keyword, description, creator, target
 			FROM dictionaries
 			WHERE source = ?
 	`, source)
@@ -91,7 +151,7 @@ func (m *MySQL) GetAllDictionaries(source string) ([]model.Dictionary, error) {
 	for rows.Next() {
 		var d model.Dictionary
 
-		if err = rows.Scan(&d.ID, &d.Source, &d.Keyword, &d.Description, &d.Creator); err != nil {
+		if err = rows.Scan(&d.ID, &d.Source, &d.Keyword, &d.Description, &d.Creator, &d.Target); err != nil {
 			return ds, err
 		}
 
@@ -101,29 +161,44 @@ func (m *MySQL) GetAllDictionaries(source string) ([]model.Dictionary, error) {
 	return ds, nil
 }
 
-func (m *MySQL) CreateEntry(entry *model.Entry) error {
-	_, err := m.db.Exec("INSERT INTO entries(source, keyword, timestamp) VALUES(?, ?, ?)",
-		entry.Source, entry.Keyword, time.Now())
+// CreateEntry returns ErrDuplicate when an entry with the same EventID
+// exists, so a redelivered webhook is never counted twice.
+func (m *MySQL) CreateEntry(ctx context.Context, entry *model.Entry) error {
+	entry.Timestamp = time.Now()
+	res, err := m.db.ExecContext(ctx, "INSERT INTO entries(source, keyword, target, event_id, timestamp) VALUES(?, ?, ?, NULLIF(?, ''), ?)",
+		entry.Source, entry.Keyword, entry.Target, entry.EventID, entry.Timestamp)
+	if merr, ok := err.(*mysql.MySQLError); ok && merr.Number == errDuplicateKey {
+		return ErrDuplicate
+	}
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	entry.ID = int(id)
 	return err
 }
 
-func (m *MySQL) RemoveEntryByKeyword(source, keyword string) error {
-	_, err := m.db.Exec("DELETE FROM entries WHERE source=? AND keyword=?",
-		source, keyword)
+func (m *MySQL) RemoveEntryByKeyword(ctx context.Context, source, keyword string) error {
+	return m.RemoveEntryByTarget(ctx, source, keyword, "")
+}
+
+func (m *MySQL) RemoveEntryByTarget(ctx context.Context, source, keyword, target string) error {
+	_, err := m.db.ExecContext(ctx, "DELETE FROM entries WHERE source=? AND keyword=? AND target=?",
+		source, keyword, target)
 	return err
 }
 
-func (m *MySQL) RemoveEntryBySource(source string) error {
-	_, err := m.db.Exec("DELETE FROM entries WHERE source=?",
+func (m *MySQL) RemoveEntryBySource(ctx context.Context, source string) error {
+	_, err := m.db.ExecContext(ctx, "DELETE FROM entries WHERE source=?",
 		source)
 	return err
 }
 
-func (m *MySQL) GetAllEntries(source string) ([]model.Entry, error) {
+func (m *MySQL) GetAllEntries(ctx context.Context, source string) ([]model.Entry, error) {
 	var es []model.Entry
 
-	rows, err := m.db.Query(`
-			SELECT id, source, keyword
+	rows, err := m.db.QueryContext(ctx, `
+			SELECT id, source, keyword, target
 			FROM entries
 			WHERE source = ?
 	`, source)
@@ -135,7 +210,7 @@ func (m *MySQL) GetAllEntries(source string) ([]model.Entry, error) {
 	for rows.Next() {
 		var e model.Entry
 
-		if err = rows.Scan(&e.ID, &e.Source, &e.Keyword); err != nil {
+		if err = rows.Scan(&e.ID, &e.Source, &e.Keyword, &e.Target); err != nil {
 			return es, err
 		}
 
@@ -145,11 +220,11 @@ func (m *MySQL) GetAllEntries(source string) ([]model.Entry, error) {
 	return es, nil
 }
 
-func (m *MySQL) getEntriesByDay(source string, day int) ([]model.Entry, error) {
+func (m *MySQL) getEntriesByDay(ctx context.Context, source string, day int) ([]model.Entry, error) {
 	var es []model.Entry
 
-	rows, err := m.db.Query(`
-			SELECT id, source, keyword, timestamp
+	rows, err := m.db.QueryContext(ctx, `
+			SELECT id, source, keyword, target, timestamp
 			FROM entries
 			WHERE source = ?
 			HAVING DATEDIFF(?, timestamp) <= ?
@@ -161,9 +236,89 @@ func (m *MySQL) getEntriesByDay(source string, day int) ([]model.Entry, error) {
 	defer rows.Close()
 	for rows.Next() {
 		var e model.Entry
-		var dummy string
 
-		if err = rows.Scan(&e.ID, &e.Source, &e.Keyword, &dummy); err != nil {
+		if err = rows.Scan(&e.ID, &e.Source, &e.Keyword, &e.Target, &e.Timestamp); err != nil {
+			return es, err
+		}
+
+		es = append(es, e)
+	}
+
+	return es, nil
+}
+
+func (m *MySQL) GetMonthEntries(ctx context.Context, source string) ([]model.Entry, error) {
+	return m.getEntriesByDay(ctx, source, 30)
+}
+
+func (m *MySQL) GetWeekEntries(ctx context.Context, source string) ([]model.Entry, error) {
+	return m.getEntriesByDay(ctx, source, 7)
+}
+
+func (m *MySQL) GetDayEntries(ctx context.Context, source string) ([]model.Entry, error) {
+	return m.getEntriesByDay(ctx, source, 1)
+}
+
+func (m *MySQL) GetEntriesBetween(ctx context.Context, source string, from, to time.Time) ([]model.Entry, error) {
+	var es []model.Entry
+
+	rows, err := m.db.QueryContext(ctx, `
+			SELECT id, source, keyword, target, timestamp
+			FROM entries
+			WHERE source = ? AND timestamp >= ? AND timestamp < ?
+			ORDER BY timestamp
+	`, source, from, to)
+	if err != nil {
+		return es, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var e model.Entry
+
+		if err = rows.Scan(&e.ID, &e.Source, &e.Keyword, &e.Target, &e.Timestamp); err != nil {
+			return es, err
+		}
+
+		es = append(es, e)
+	}
+
+	return es, nil
+}
+
+// GetEntries returns a page of the entries matching f.
+func (m *MySQL) GetEntries(ctx context.Context, f EntryFilter) ([]model.Entry, error) {
+	var es []model.Entry
+
+	cond, args := "source = ? AND id > ?", []interface{}{f.Source, f.AfterID}
+	if f.Keyword != "" {
+		cond, args = cond+" AND keyword = ?", append(args, f.Keyword)
+	}
+	if f.Target != "" {
+		cond, args = cond+" AND target = ?", append(args, f.Target)
+	}
+	if !f.From.IsZero() {
+		cond, args = cond+" AND timestamp >= ?", append(args, f.From)
+	}
+	if !f.To.IsZero() {
+		cond, args = cond+" AND timestamp < ?", append(args, f.To)
+	}
+	rows, err := m.db.QueryContext(ctx, `
+			SELECT id, source, keyword, target, timestamp
+			FROM entries
+			WHERE `+cond+`
+			ORDER BY id
+			LIMIT ?
+	`, append(args, f.Limit)...)
+	if err != nil {
+		return es, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var e model.Entry
+
+		if err = rows.Scan(&e.ID, &e.Source, &e.Keyword, &e.Target, &e.Timestamp); err != nil {
 			return es, err
 		}
 
@@ -173,14 +328,99 @@ func (m *MySQL) getEntriesByDay(source string, day int) ([]model.Entry, error) {
 	return es, nil
 }
 
-func (m *MySQL) GetMonthEntries(source string) ([]model.Entry, error) {
-	return m.getEntriesByDay(source, 30)
+// GetGroup returns the settings of a source, falling back to the defaults
+// when the source has never saved any.
+func (m *MySQL) GetGroup(ctx context.Context, source string) (model.Group, error) {
+	g := model.Group{Source: source, Timezone: util.DEFAULT_TIMEZONE}
+
+	err := m.db.QueryRowContext(ctx, "SELECT source, timezone, recap, auto_season, style, left_at, subscribed_at, timestamp FROM group_settings WHERE source = ?", source).Scan(&g.Source, &g.Timezone, &g.Recap, &g.AutoSeason, &g.Style, &g.LeftAt, &g.SubscribedAt, &g.Timestamp)
+	if err == sql.ErrNoRows {
+		return g, nil
+	}
+	if err != nil {
+		return model.Group{}, err
+	}
+
+	return g, nil
 }
 
-func (m *MySQL) GetWeekEntries(source string) ([]model.Entry, error) {
-	return m.getEntriesByDay(source, 7)
+func (m *MySQL) SaveGroup(ctx context.Context, g *model.Group) error {
+	g.Timestamp = time.Now()
+	_, err := m.db.ExecContext(ctx, `
+			INSERT INTO group_settings(source, timezone, recap, auto_season, style, left_at, subscribed_at, timestamp) VALUES(?, ?, ?, ?, ?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE timezone = VALUES(timezone), recap = VALUES(recap), auto_season = VALUES(auto_season), style = VALUES(style), left_at = VALUES(left_at), subscribed_at = VALUES(subscribed_at), timestamp = VALUES(timestamp)
+	`, g.Source, g.Timezone, g.Recap, g.AutoSeason, g.Style, g.LeftAt, g.SubscribedAt, g.Timestamp)
+	return err
 }
 
-func (m *MySQL) GetDayEntries(source string) ([]model.Entry, error) {
-	return m.getEntriesByDay(source, 1)
+func (m *MySQL) getGroupsWhere(ctx context.Context, cond string, args ...interface{}) ([]model.Group, error) {
+	var gs []model.Group
+
+	rows, err := m.db.QueryContext(ctx, `
+			SELECT source, timezone, recap, auto_season, style, left_at, subscribed_at, timestamp
+			FROM group_settings
+			WHERE `+cond, args...)
+	if err != nil {
+		return gs, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var g model.Group
+
+		if err = rows.Scan(&g.Source, &g.Timezone, &g.Recap, &g.AutoSeason, &g.Style, &g.LeftAt, &g.SubscribedAt, &g.Timestamp); err != nil {
+			return gs, err
+		}
+
+		gs = append(gs, g)
+	}
+
+	return gs, nil
+}
+
+func (m *MySQL) GetGroupsByRecap(ctx context.Context, recap string) ([]model.Group, error) {
+	return m.getGroupsWhere(ctx, "recap = ? AND left_at IS NULL", recap)
+}
+
+func (m *MySQL) GetAutoSeasonGroups(ctx context.Context) ([]model.Group, error) {
+	return m.getGroupsWhere(ctx, "auto_season = ? AND left_at IS NULL", true)
+}
+
+// GetGroupsLeftBefore returns the groups the bot left before t.
+func (m *MySQL) GetGroupsLeftBefore(ctx context.Context, t time.Time) ([]model.Group, error) {
+	return m.getGroupsWhere(ctx, "left_at < ?", t)
+}
+
+func (m *MySQL) RemoveGroup(ctx context.Context, source string) error {
+	_, err := m.db.ExecContext(ctx, "DELETE FROM group_settings WHERE source=?",
+		source)
+	return err
+}
+
+func (m *MySQL) CreateJobRun(ctx context.Context, r *model.JobRun) error {
+	_, err := m.db.ExecContext(ctx, "INSERT INTO job_runs(job, source, owner, status, error, attempt, scheduled_at, started_at, finished_at) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		r.Job, r.Source, r.Owner, r.Status, r.Error, r.Attempt, r.ScheduledAt, r.StartedAt, r.FinishedAt)
+	return err
+}
+
+// GetLastJobRun returns the latest recorded run of a job for a source. A zero
+// JobRun is returned when the job never ran there.
+func (m *MySQL) GetLastJobRun(ctx context.Context, job, source string) (model.JobRun, error) {
+	var r model.JobRun
+
+	err := m.db.QueryRowContext(ctx, `
+			SELECT id, job, source, owner, status, error, attempt, scheduled_at, started_at, finished_at
+			FROM job_runs
+			WHERE job = ? AND source = ?
+			ORDER BY scheduled_at DESC, id DESC
+			LIMIT 1
+	`, job, source).Scan(&r.ID, &r.Job, &r.Source, &r.Owner, &r.Status, &r.Error, &r.Attempt, &r.ScheduledAt, &r.StartedAt, &r.FinishedAt)
+	if err == sql.ErrNoRows {
+		return model.JobRun{}, nil
+	}
+	if err != nil {
+		return model.JobRun{}, err
+	}
+
+	return r, nil
 }
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/luqmanarifin/kentang/model"
+)
+
+func (m *MySQL) GetEntriesByKeyword(ctx context.Context, source, keyword string) ([]model.Entry, error) {
+	var es []model.Entry
+
+	rows, err := m.db.QueryContext(ctx, `
+			SELECT id, source, keyword, target, timestamp
+			FROM entries
+			WHERE source = ? AND keyword = ? AND target = ''
+			ORDER BY timestamp
+	`, source, keyword)
+	if err != nil {
+		return es, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var e model.Entry
+
+		if err = rows.Scan(&e.ID, &e.Source, &e.Keyword, &e.Target, &e.Timestamp); err != nil {
+			return es, err
+		}
+
+		es = append(es, e)
+	}
+
+	return es, nil
+}
+
+func (m *MySQL) GetEntriesByKeywordSince(ctx context.Context, source, keyword string, from time.Time) ([]model.Entry, error) {
+	var es []model.Entry
+
+	rows, err := m.db.QueryContext(ctx, `
+			SELECT id, source, keyword, target, timestamp
+			FROM entries
+			WHERE source = ? AND keyword = ? AND target = '' AND timestamp >= ?
+			ORDER BY timestamp
+	`, source, keyword, from)
+	if err != nil {
+		return es, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var e model.Entry
+
+		if err = rows.Scan(&e.ID, &e.Source, &e.Keyword, &e.Target, &e.Timestamp); err != nil {
+			return es, err
+		}
+
+		es = append(es, e)
+	}
+
+	return es, nil
+}
+
+func (m *MySQL) CountEntriesByKeyword(ctx context.Context, source, keyword string) (int, error) {
+	var count int
+	err := m.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM entries WHERE source = ? AND keyword = ? AND target = ''", source, keyword).Scan(&count)
+	return count, err
+}
+
+func (m *MySQL) CreateBadge(ctx context.Context, b *model.Badge) error {
+	b.Timestamp = time.Now()
+	_, err := m.db.ExecContext(ctx, "INSERT IGNORE INTO badges(source, keyword, name, timestamp) VALUES(?, ?, ?, ?)",
+		b.Source, b.Keyword, b.Name, b.Timestamp)
+	return err
+}
+
+func (m *MySQL) GetBadges(ctx context.Context, source, keyword string) ([]model.Badge, error) {
+	var bs []model.Badge
+
+	rows, err := m.db.QueryContext(ctx, `
+			SELECT id, source, keyword, name, timestamp
+			FROM badges
+			WHERE source = ? AND keyword = ?
+			ORDER BY timestamp
+	`, source, keyword)
+	if err != nil {
+		return bs, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var b model.Badge
+
+		if err = rows.Scan(&b.ID, &b.Source, &b.Keyword, &b.Name, &b.Timestamp); err != nil {
+			return bs, err
+		}
+
+		bs = append(bs, b)
+	}
+
+	return bs, nil
+}
+
+func (m *MySQL) RemoveBadgeByKeyword(ctx context.Context, source, keyword string) error {
+	_, err := m.db.ExecContext(ctx, "DELETE FROM badges WHERE source=? AND keyword=?",
+		source, keyword)
+	return err
+}
+
+func (m *MySQL) RemoveBadgeBySource(ctx context.Context, source string) error {
+	_, err := m.db.ExecContext(ctx, "DELETE FROM badges WHERE source=?",
+		source)
+	return err
+}
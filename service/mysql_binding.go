@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/luqmanarifin/kentang/model"
+)
+
+// SaveBinding creates a binding, or points an existing one at a new command.
+func (m *MySQL) SaveBinding(ctx context.Context, b *model.Binding) error {
+	b.Timestamp = time.Now()
+	_, err := m.db.ExecContext(ctx, `
+			INSERT INTO bindings(source, type, `+"`key`"+`, command, creator, timestamp) VALUES(?, ?, ?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE command = VALUES(command), creator = VALUES(creator), timestamp = VALUES(timestamp)
+	`, b.Source, b.Type, b.Key, b.Command, b.Creator, b.Timestamp)
+	return err
+}
+
+func (m *MySQL) GetBinding(ctx context.Context, source, typ, key string) (model.Binding, error) {
+	var b model.Binding
+
+	err := m.db.QueryRowContext(ctx, "SELECT id, source, type, `key`, command, creator, timestamp FROM bindings WHERE source = ? AND type = ? AND `key` = ?", source, typ, key).Scan(&b.ID, &b.Source, &b.Type, &b.Key, &b.Command, &b.Creator, &b.Timestamp)
+	if err != nil {
+		return model.Binding{}, err
+	}
+
+	return b, nil
+}
+
+func (m *MySQL) GetAllBindings(ctx context.Context, source string) ([]model.Binding, error) {
+	var bs []model.Binding
+
+	rows, err := m.db.QueryContext(ctx, "SELECT id, source, type, `key`, command, creator, timestamp FROM bindings WHERE source = ? ORDER BY type, command", source)
+	if err != nil {
+		return bs, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var b model.Binding
+
+		if err = rows.Scan(&b.ID, &b.Source, &b.Type, &b.Key, &b.Command, &b.Creator, &b.Timestamp); err != nil {
+			return bs, err
+		}
+
+		bs = append(bs, b)
+	}
+
+	return bs, nil
+}
+
+func (m *MySQL) RemoveBinding(ctx context.Context, source, typ, key string) error {
+	_, err := m.db.ExecContext(ctx, "DELETE FROM bindings WHERE source=? AND type=? AND `key`=?",
+		source, typ, key)
+	return err
+}
+
+func (m *MySQL) RemoveBindingByCommand(ctx context.Context, source, typ, command string) error {
+	_, err := m.db.ExecContext(ctx, "DELETE FROM bindings WHERE source=? AND type=? AND command=?",
+		source, typ, command)
+	return err
+}
+
+func (m *MySQL) RemoveBindingBySource(ctx context.Context, source string) error {
+	_, err := m.db.ExecContext(ctx, "DELETE FROM bindings WHERE source=?",
+		source)
+	return err
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/luqmanarifin/kentang/model"
+)
+
+// CreateSeason archives a season together with its standings.
+func (m *MySQL) CreateSeason(ctx context.Context, s *model.Season, standings []model.Standing) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, "INSERT INTO seasons(source, name, started_at, ended_at) VALUES(?, ?, ?, ?)",
+		s.Source, s.Name, s.StartedAt, s.EndedAt)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	s.ID = int(id)
+
+	for _, st := range standings {
+		_, err = tx.ExecContext(ctx, "INSERT INTO standings(season_id, source, position, keyword, description, count) VALUES(?, ?, ?, ?, ?, ?)",
+			s.ID, s.Source, st.Position, st.Keyword, st.Description, st.Count)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetLastSeason returns the most recently ended season of a source, or a zero
+// Season when none has ended yet.
+func (m *MySQL) GetLastSeason(ctx context.Context, source string) (model.Season, error) {
+	var s model.Season
+
+	err := m.db.QueryRowContext(ctx, `
+			SELECT id, source, name, started_at, ended_at
+			FROM seasons
+			WHERE source = ?
+			ORDER BY ended_at DESC
+			LIMIT 1
+	`, source).Scan(&s.ID, &s.Source, &s.Name, &s.StartedAt, &s.EndedAt)
+	if err == sql.ErrNoRows {
+		return model.Season{}, nil
+	}
+	if err != nil {
+		return model.Season{}, err
+	}
+
+	return s, nil
+}
+
+func (m *MySQL) GetSeasonByName(ctx context.Context, source, name string) (model.Season, error) {
+	var s model.Season
+
+	err := m.db.QueryRowContext(ctx, "SELECT id, source, name, started_at, ended_at FROM seasons WHERE source = ? AND name = ?", source, name).Scan(&s.ID, &s.Source, &s.Name, &s.StartedAt, &s.EndedAt)
+	if err != nil {
+		return model.Season{}, err
+	}
+
+	return s, nil
+}
+
+func (m *MySQL) GetSeasons(ctx context.Context, source string) ([]model.Season, error) {
+	var ss []model.Season
+
+	rows, err := m.db.QueryContext(ctx, `
+			SELECT id, source, name, started_at, ended_at
+			FROM seasons
+			WHERE source = ?
+			ORDER BY ended_at
+	`, source)
+	if err != nil {
+		return ss, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var s model.Season
+
+		if err = rows.Scan(&s.ID, &s.Source, &s.Name, &s.StartedAt, &s.EndedAt); err != nil {
+			return ss, err
+		}
+
+		ss = append(ss, s)
+	}
+
+	return ss, nil
+}
+
+func (m *MySQL) getStandingsWhere(ctx context.Context, cond string, args ...interface{}) ([]model.Standing, error) {
+	var sts []model.Standing
+
+	rows, err := m.db.QueryContext(ctx, `
+			SELECT id, season_id, source, position, keyword, description, count
+			FROM standings
+			WHERE `+cond+`
+			ORDER BY season_id, position
+	`, args...)
+	if err != nil {
+		return sts, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var st model.Standing
+
+		if err = rows.Scan(&st.ID, &st.SeasonID, &st.Source, &st.Position, &st.Keyword, &st.Description, &st.Count); err != nil {
+			return sts, err
+		}
+
+		sts = append(sts, st)
+	}
+
+	return sts, nil
+}
+
+func (m *MySQL) GetStandings(ctx context.Context, seasonID int) ([]model.Standing, error) {
+	return m.getStandingsWhere(ctx, "season_id = ?", seasonID)
+}
+
+// GetChampions returns the first placed standings of every season of a source.
+func (m *MySQL) GetChampions(ctx context.Context, source string) ([]model.Standing, error) {
+	return m.getStandingsWhere(ctx, "source = ? AND position = 1", source)
+}
+
+func (m *MySQL) RemoveSeasonBySource(ctx context.Context, source string) error {
+	_, err := m.db.ExecContext(ctx, "DELETE FROM standings WHERE source=?",
+		source)
+	if err != nil {
+		return err
+	}
+	_, err = m.db.ExecContext(ctx, "DELETE FROM seasons WHERE source=?",
+		source)
+	return err
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"os"
 	"testing"
 	"time"
@@ -44,7 +45,7 @@ func TestCreateDictionary(t *testing.T) {
 		Creator:     "luqman",
 		Timestamp:   time.Now(),
 	}
-	err = m.CreateDictionary(dict)
+	err = m.CreateDictionary(context.Background(), dict)
 	if err != nil {
 		t.Fatalf("%s", err.Error())
 	}
@@ -60,7 +61,7 @@ func TestRemoveDictionary(t *testing.T) {
 		Keyword:     "a",
 		Description: "b",
 	}
-	err = m.RemoveDictionary(dict)
+	err = m.RemoveDictionary(context.Background(), dict)
 	if err != nil {
 		t.Fatalf("%s", err.Error())
 	}
@@ -71,7 +72,7 @@ func TestGetDictionary(t *testing.T) {
 	if err != nil {
 		t.Fatal("error connecting")
 	}
-	dict, err := m.GetDictionary(1)
+	dict, err := m.GetDictionary(context.Background(), 1)
 	if err != nil {
 		t.Fatalf("%s", err.Error())
 	}
@@ -84,7 +85,7 @@ func TestGetDictionaryByKeyword(t *testing.T) {
 	if err != nil {
 		t.Fatal("error connecting")
 	}
-	dict, err := m.GetDictionaryByKeyword("source", "bird")
+	dict, err := m.GetDictionaryByKeyword(context.Background(), "source", "bird")
 	if err != nil {
 		t.Fatalf("%s", err.Error())
 	}
@@ -97,7 +98,7 @@ func TestGetAllDictionaries(t *testing.T) {
 	if err != nil {
 		t.Fatal("error connecting")
 	}
-	dict, err := m.GetAllDictionaries("source")
+	dict, err := m.GetAllDictionaries(context.Background(), "source")
 	if err != nil {
 		t.Fatalf("%s", err.Error())
 	}
@@ -115,7 +116,7 @@ func TestCreateEntry(t *testing.T) {
 		Source:  "source",
 		Keyword: "asuasu",
 	}
-	err = m.CreateEntry(entry)
+	err = m.CreateEntry(context.Background(), entry)
 	if err != nil {
 		t.Fatalf("%s", err.Error())
 	}
@@ -130,7 +131,7 @@ func TestRemoveEntryByKeyword(t *testing.T) {
 		Source:  "source",
 		Keyword: "asuasu",
 	}
-	err = m.RemoveEntryByKeyword(entry.Source, entry.Keyword)
+	err = m.RemoveEntryByKeyword(context.Background(), entry.Source, entry.Keyword)
 	if err != nil {
 		t.Fatalf("%s", err.Error())
 	}
@@ -145,7 +146,7 @@ func TestRemoveEntryBySource(t *testing.T) {
 		Source:  "luqman",
 		Keyword: "a",
 	}
-	err = m.RemoveEntryBySource(entry.Source)
+	err = m.RemoveEntryBySource(context.Background(), entry.Source)
 	if err != nil {
 		t.Fatalf("%s", err.Error())
 	}
@@ -156,7 +157,7 @@ func TestGetAllEntries(t *testing.T) {
 	if err != nil {
 		t.Fatal("error connecting")
 	}
-	entries, err := m.GetAllEntries("source")
+	entries, err := m.GetAllEntries(context.Background(), "source")
 	if err != nil {
 		t.Fatalf("%s", err.Error())
 	}
@@ -170,7 +171,7 @@ func TestGetMonthEntries(t *testing.T) {
 	if err != nil {
 		t.Fatal("error connecting")
 	}
-	entries, err := m.GetMonthEntries("source")
+	entries, err := m.GetMonthEntries(context.Background(), "source")
 	if err != nil {
 		t.Fatalf("%s", err.Error())
 	}
@@ -1,10 +1,13 @@
 package service
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"log/slog"
 	"time"
 
-	"github.com/go-redis/redis"
+	"github.com/go-redis/redis/v8"
+	"github.com/luqmanarifin/kentang/metrics"
 	"github.com/luqmanarifin/kentang/util"
 )
 
@@ -20,53 +23,147 @@ type RedisOption struct {
 	Database int
 }
 
+// String tells where opt connects to, without the password.
+func (opt RedisOption) String() string {
+	return fmt.Sprintf("%s:%s/%d", opt.Host, opt.Port, opt.Database)
+}
+
 func NewRedis(opt RedisOption) (*Redis, error) {
 	client := redis.NewClient(&redis.Options{
 		Addr:     opt.Host + ":" + opt.Port,
 		Password: opt.Password,
 		DB:       opt.Database,
 	})
-	pong, err := client.Ping().Result()
-	log.Println(pong, err)
+	_, err := client.Ping(context.Background()).Result()
 	if err != nil {
 		return &Redis{}, err
 	}
 
-	log.Printf("Success connecting Redis to %s:%s with pass %s\n", opt.Host, opt.Port, opt.Password)
+	slog.Info("Connected to Redis", "redis", opt.String())
+	client.AddHook(timeCommands{})
 	return &Redis{db: client}, nil
 }
 
+// Ping checks that Redis answers.
+func (r *Redis) Ping(ctx context.Context) error {
+	return r.db.Ping(ctx).Err()
+}
+
+func (r *Redis) Close() error {
+	return r.db.Close()
+}
+
 // 0 not exist, 1 exist, -1 don't know
-func (r *Redis) GetKeyword(source, keyword string) (string, error) {
-	val, err := r.db.Get(source + ":" + keyword).Result()
-	if err == redis.Nil {
+func (r *Redis) GetKeyword(ctx context.Context, source, keyword string) (string, error) {
+	val, err := r.db.Get(ctx, source+":"+keyword).Result()
+	metrics.Cache("keyword", err == nil)
+	if err != nil {
 		return "", err
 	}
 	return val, nil
 }
 
-func (r *Redis) AddKeyword(source, keyword, val string) error {
-	return r.db.Set(source+":"+keyword, val, 0).Err()
+func (r *Redis) AddKeyword(ctx context.Context, source, keyword, val string) error {
+	return r.db.Set(ctx, source+":"+keyword, val, 0).Err()
 }
 
-func (r *Redis) RemoveKeyword(source, keyword string) error {
-	return r.AddKeyword(source, keyword, util.NOT_EXIST)
+func (r *Redis) RemoveKeyword(ctx context.Context, source, keyword string) error {
+	return r.AddKeyword(ctx, source, keyword, util.NOT_EXIST)
 }
 
-func (r *Redis) RemoveAllKeyword(source string) error {
-	script := "return redis.call('DEL', unpack(redis.call('KEYS', ARGV[1] .. '*')))"
-	s := make([]string, 0)
-	return r.db.Eval(script, s, source+":").Err()
+// RemoveAllKeyword deletes the cached keywords of source, if there are any.
+// It scans for them rather than using KEYS, which blocks Redis.
+func (r *Redis) RemoveAllKeyword(ctx context.Context, source string) error {
+	var keys []string
+	iter := r.db.Scan(ctx, 0, source+":*", 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	return r.db.Del(ctx, keys...).Err()
 }
 
-func (r *Redis) GetDisplayName(userId string) (string, error) {
-	name, err := r.db.Get(userId).Result()
+func (r *Redis) GetDisplayName(ctx context.Context, userId string) (string, error) {
+	name, err := r.db.Get(ctx, userId).Result()
+	metrics.Cache("display_name", err == nil)
 	if err != nil {
 		return "", err
 	}
 	return name, nil
 }
 
-func (r *Redis) SetDisplayName(userId, name string) error {
-	return r.db.Set(userId, name, 10*24*time.Hour).Err()
+func (r *Redis) SetDisplayName(ctx context.Context, userId, name string) error {
+	return r.db.Set(ctx, userId, name, 10*24*time.Hour).Err()
+}
+
+// AcquireLock sets key to owner only if nobody holds it yet. The lock expires
+// after ttl so a crashed owner can't hold it forever.
+func (r *Redis) AcquireLock(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	return r.db.SetNX(ctx, key, owner, ttl).Result()
+}
+
+// ReleaseLock deletes key, but only when it is still held by owner.
+func (r *Redis) ReleaseLock(ctx context.Context, key, owner string) error {
+	script := "if redis.call('GET', KEYS[1]) == ARGV[1] then return redis.call('DEL', KEYS[1]) else return 0 end"
+	return r.db.Eval(ctx, script, []string{key}, owner).Err()
+}
+
+func (r *Redis) SetChart(ctx context.Context, id string, png []byte, ttl time.Duration) error {
+	return r.db.Set(ctx, "chart:"+id, png, ttl).Err()
+}
+
+func (r *Redis) GetChart(ctx context.Context, id string) ([]byte, error) {
+	return r.db.Get(ctx, "chart:"+id).Bytes()
+}
+
+func (r *Redis) GetPictureURL(ctx context.Context, userId string) (string, error) {
+	return r.db.Get(ctx, "picture:"+userId).Result()
+}
+
+func (r *Redis) SetPictureURL(ctx context.Context, userId, url string) error {
+	return r.db.Set(ctx, "picture:"+userId, url, 10*24*time.Hour).Err()
+}
+
+// MarkEvent records that the webhook event id was handled. It returns false
+// when it already was, i.e. the event is a redelivery.
+func (r *Redis) MarkEvent(ctx context.Context, id string, ttl time.Duration) (bool, error) {
+	return r.db.SetNX(ctx, "event:"+id, 1, ttl).Result()
+}
+
+// EventMarked tells whether the webhook event id was handled.
+func (r *Redis) EventMarked(ctx context.Context, id string) (bool, error) {
+	n, err := r.db.Exists(ctx, "event:"+id).Result()
+	return n > 0, err
+}
+
+// UseNonce records that the one-time nonce was used. It returns false when it
+// already was.
+func (r *Redis) UseNonce(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	return r.db.SetNX(ctx, "nonce:"+nonce, 1, ttl).Result()
+}
+
+// RemoveProfile forgets the cached display name and picture of a user.
+func (r *Redis) RemoveProfile(ctx context.Context, userId string) error {
+	return r.db.Del(ctx, userId, "picture:"+userId).Err()
+}
+
+// SetPending remembers that a user started a command which finishes with
+// their next message, e.g. add-sticker waiting for the sticker.
+func (r *Redis) SetPending(ctx context.Context, source, userId, kind, val string, ttl time.Duration) error {
+	return r.db.Set(ctx, "pending:"+kind+":"+source+":"+userId, val, ttl).Err()
+}
+
+// TakePending returns and forgets what SetPending remembered.
+func (r *Redis) TakePending(ctx context.Context, source, userId, kind string) (string, error) {
+	key := "pending:" + kind + ":" + source + ":" + userId
+	val, err := r.db.Get(ctx, key).Result()
+	if err != nil {
+		return "", err
+	}
+	return val, r.db.Del(ctx, key).Err()
 }
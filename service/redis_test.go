@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"os"
 	"strconv"
 	"testing"
@@ -26,16 +27,23 @@ func getRedisConnection(t *testing.T) (*Redis, error) {
 
 func TestGetKeyword(t *testing.T) {
 	r, _ := getRedisConnection(t)
-	val, _ := r.GetKeyword("source", "kentang")
+	val, _ := r.GetKeyword(context.Background(), "source", "kentang")
 	t.Logf("val %s", val)
 }
 
 func TestAddKeyword(t *testing.T) {
 	r, _ := getRedisConnection(t)
-	r.AddKeyword("source", "lala", "1")
+	r.AddKeyword(context.Background(), "source", "lala", "1")
 }
 
 func TestRemoveAllKeyword(t *testing.T) {
 	r, _ := getRedisConnection(t)
-	r.RemoveAllKeyword("source")
+	r.AddKeyword(context.Background(), "source", "lala", "1")
+	if err := r.RemoveAllKeyword(context.Background(), "source"); err != nil {
+		t.Fatal(err)
+	}
+	// nothing is left to remove
+	if err := r.RemoveAllKeyword(context.Background(), "source"); err != nil {
+		t.Fatal(err)
+	}
 }
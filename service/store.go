@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/luqmanarifin/kentang/model"
+)
+
+// ErrDuplicate is returned when a row with the same unique key was already
+// stored.
+var ErrDuplicate = errors.New("duplicate entry")
+
+// EntryFilter narrows the entries of a source. Empty fields match anything.
+// Entries come ordered by ID, Limit of them after the one with AfterID.
+type EntryFilter struct {
+	Source  string
+	Keyword string
+	Target  string
+	From    time.Time
+	To      time.Time
+	AfterID int
+	Limit   int
+}
+
+// Store keeps the dictionaries, entries and everything around them. MySQL is
+// the one used in production, Memory keeps everything in process.
+type Store interface {
+	GetSources(ctx context.Context) ([]string, error)
+
+	CreateDictionary(ctx context.Context, d *model.Dictionary) error
+	RemoveDictionaryBySource(ctx context.Context, source string) error
+	RemoveDictionary(ctx context.Context, d *model.Dictionary) error
+	UpdateDictionary(ctx context.Context, d *model.Dictionary) error
+	GetDictionary(ctx context.Context, id int) (model.Dictionary, error)
+	GetDictionaryByKeyword(ctx context.Context, source, keyword string) (model.Dictionary, error)
+	GetDictionaryByTarget(ctx context.Context, source, keyword, target string) (model.Dictionary, error)
+	GetAllDictionaries(ctx context.Context, source string) ([]model.Dictionary, error)
+
+	CreateEntry(ctx context.Context, entry *model.Entry) error
+	RemoveEntryByKeyword(ctx context.Context, source, keyword string) error
+	RemoveEntryByTarget(ctx context.Context, source, keyword, target string) error
+	RemoveEntryBySource(ctx context.Context, source string) error
+	GetAllEntries(ctx context.Context, source string) ([]model.Entry, error)
+	GetMonthEntries(ctx context.Context, source string) ([]model.Entry, error)
+	GetWeekEntries(ctx context.Context, source string) ([]model.Entry, error)
+	GetDayEntries(ctx context.Context, source string) ([]model.Entry, error)
+	GetEntriesBetween(ctx context.Context, source string, from, to time.Time) ([]model.Entry, error)
+	GetEntriesByKeyword(ctx context.Context, source, keyword string) ([]model.Entry, error)
+	GetEntriesByKeywordSince(ctx context.Context, source, keyword string, from time.Time) ([]model.Entry, error)
+	CountEntriesByKeyword(ctx context.Context, source, keyword string) (int, error)
+	GetEntries(ctx context.Context, f EntryFilter) ([]model.Entry, error)
+
+	GetGroup(ctx context.Context, source string) (model.Group, error)
+	SaveGroup(ctx context.Context, g *model.Group) error
+	GetGroupsByRecap(ctx context.Context, recap string) ([]model.Group, error)
+	GetAutoSeasonGroups(ctx context.Context) ([]model.Group, error)
+	GetGroupsLeftBefore(ctx context.Context, t time.Time) ([]model.Group, error)
+	RemoveGroup(ctx context.Context, source string) error
+
+	CreateJobRun(ctx context.Context, r *model.JobRun) error
+	GetLastJobRun(ctx context.Context, job, source string) (model.JobRun, error)
+
+	CreateBadge(ctx context.Context, b *model.Badge) error
+	GetBadges(ctx context.Context, source, keyword string) ([]model.Badge, error)
+	RemoveBadgeByKeyword(ctx context.Context, source, keyword string) error
+	RemoveBadgeBySource(ctx context.Context, source string) error
+
+	SaveBinding(ctx context.Context, b *model.Binding) error
+	GetBinding(ctx context.Context, source, typ, key string) (model.Binding, error)
+	GetAllBindings(ctx context.Context, source string) ([]model.Binding, error)
+	RemoveBinding(ctx context.Context, source, typ, key string) error
+	RemoveBindingByCommand(ctx context.Context, source, typ, command string) error
+	RemoveBindingBySource(ctx context.Context, source string) error
+
+	CreateSeason(ctx context.Context, s *model.Season, standings []model.Standing) error
+	GetLastSeason(ctx context.Context, source string) (model.Season, error)
+	GetSeasonByName(ctx context.Context, source, name string) (model.Season, error)
+	GetSeasons(ctx context.Context, source string) ([]model.Season, error)
+	GetStandings(ctx context.Context, seasonID int) ([]model.Standing, error)
+	GetChampions(ctx context.Context, source string) ([]model.Standing, error)
+	RemoveSeasonBySource(ctx context.Context, source string) error
+}
+
+// Cache holds what is cheap to lose: keyword lookups, profiles, locks and
+// short lived state. Redis is the one used in production, MemoryCache keeps
+// it in process.
+type Cache interface {
+	GetKeyword(ctx context.Context, source, keyword string) (string, error)
+	AddKeyword(ctx context.Context, source, keyword, val string) error
+	RemoveKeyword(ctx context.Context, source, keyword string) error
+	RemoveAllKeyword(ctx context.Context, source string) error
+
+	GetDisplayName(ctx context.Context, userId string) (string, error)
+	SetDisplayName(ctx context.Context, userId, name string) error
+	GetPictureURL(ctx context.Context, userId string) (string, error)
+	SetPictureURL(ctx context.Context, userId, url string) error
+	RemoveProfile(ctx context.Context, userId string) error
+
+	MarkEvent(ctx context.Context, id string, ttl time.Duration) (bool, error)
+	EventMarked(ctx context.Context, id string) (bool, error)
+	UseNonce(ctx context.Context, nonce string, ttl time.Duration) (bool, error)
+
+	AcquireLock(ctx context.Context, key, owner string, ttl time.Duration) (bool, error)
+	ReleaseLock(ctx context.Context, key, owner string) error
+
+	SetChart(ctx context.Context, id string, png []byte, ttl time.Duration) error
+	GetChart(ctx context.Context, id string) ([]byte, error)
+
+	SetPending(ctx context.Context, source, userId, kind, val string, ttl time.Duration) error
+	TakePending(ctx context.Context, source, userId, kind string) (string, error)
+}
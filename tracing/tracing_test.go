@@ -0,0 +1,77 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func record(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+	return exporter
+}
+
+func TestHandlerAndTransport(t *testing.T) {
+	exporter := record(t)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer api.Close()
+	client := &http.Client{Transport: Transport(http.DefaultTransport, func(r *http.Request) string { return "api " + r.URL.Path })}
+
+	var queued context.Context
+	handler := Handler("/callback", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, _ := http.NewRequestWithContext(r.Context(), http.MethodPost, api.URL+"/reply", nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		queued = Inherit(context.Background(), r.Context())
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/callback", nil))
+	_, later := Start(queued, "event")
+	later.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want 3", len(spans))
+	}
+	byName := make(map[string]tracetest.SpanStub)
+	for _, span := range spans {
+		byName[span.Name] = span
+	}
+	server, call, event := byName["POST /callback"], byName["api /reply"], byName["event"]
+	if server.SpanKind != trace.SpanKindServer || call.SpanKind != trace.SpanKindClient {
+		t.Errorf("got kinds %v and %v", server.SpanKind, call.SpanKind)
+	}
+	for _, child := range []tracetest.SpanStub{call, event} {
+		if child.Parent.SpanID() != server.SpanContext.SpanID() {
+			t.Errorf("%s isn't a child of the request span", child.Name)
+		}
+	}
+	if server.Status.Code != codes.Error || call.Status.Code != codes.Error {
+		t.Errorf("got statuses %v and %v, want errors", server.Status, call.Status)
+	}
+}
+
+func TestSetupWithoutEndpoint(t *testing.T) {
+	shutdown, err := Setup(context.Background(), "", "kentang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
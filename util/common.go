@@ -25,12 +25,19 @@ func (s ByKey) Less(i, j int) bool {
 	return s[i].Key > s[j].Key
 }
 
+// EntriesToSortedMap ranks keywords by their entries. Entries counted
+// against a person are left out, they are ranked on their own.
 func EntriesToSortedMap(entries []model.Entry) []Pair {
 	m := make(map[string]int)
 	for _, entry := range entries {
-		m[entry.Keyword]++
+		if entry.Target == "" {
+			m[entry.Keyword]++
+		}
 	}
+	return MapToSortedPairs(m)
+}
 
+func MapToSortedPairs(m map[string]int) []Pair {
 	var pairs []Pair
 	for k, v := range m {
 		pairs = append(pairs, Pair{
@@ -41,3 +48,16 @@ func EntriesToSortedMap(entries []model.Entry) []Pair {
 	sort.Sort(ByKey(pairs))
 	return pairs
 }
+
+// Positions ranks sorted pairs, giving tied counts the same position.
+func Positions(pairs []Pair) []int {
+	positions := make([]int, len(pairs))
+	for i := range pairs {
+		if i > 0 && pairs[i].Key == pairs[i-1].Key {
+			positions[i] = positions[i-1]
+		} else {
+			positions[i] = i + 1
+		}
+	}
+	return positions
+}
@@ -31,3 +31,13 @@ func TestEntriesToSortedMap(t *testing.T) {
 		log.Printf("%s: %d\n", pair.Value, pair.Key)
 	}
 }
+
+func TestPositions(t *testing.T) {
+	pairs := []Pair{{Key: 5, Value: "luq"}, {Key: 4, Value: "niki"}, {Key: 4, Value: "bird"}, {Key: 1, Value: "asu"}}
+	want := []int{1, 2, 2, 4}
+	for i, got := range Positions(pairs) {
+		if got != want[i] {
+			t.Errorf("position of %s: got %d, want %d", pairs[i].Value, got, want[i])
+		}
+	}
+}
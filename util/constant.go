@@ -1,3 +1,10 @@
 package util
 
 const NOT_EXIST = "NOT_EXIST"
+
+const DEFAULT_TIMEZONE = "Asia/Jakarta"
+
+const (
+	STYLE_TEXT = "text"
+	STYLE_FLEX = "flex"
+)
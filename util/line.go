@@ -3,8 +3,11 @@ package util
 import (
 	"fmt"
 	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf16"
 
-	"github.com/line/line-bot-sdk-go/linebot"
+	"github.com/line/line-bot-sdk-go/v7/linebot"
 )
 
 var (
@@ -58,3 +61,48 @@ func StringsToLineEventSources(ss []string) ([]*linebot.EventSource, error) {
 	}
 	return ess, nil
 }
+
+// MentionedUser is a user mentioned in a message, with the name the message
+// shows for them.
+type MentionedUser struct {
+	UserID string
+	Name   string
+}
+
+// SplitMentions cuts the mentions out of text. It returns the rest of the
+// text and the mentioned users in order. Mention indexes count UTF-16 code
+// units, like LINE does.
+func SplitMentions(text string, mention *linebot.Mention) (string, []MentionedUser) {
+	if mention == nil || len(mention.Mentionees) == 0 {
+		return text, nil
+	}
+	mentionees := make([]*linebot.Mentionee, len(mention.Mentionees))
+	copy(mentionees, mention.Mentionees)
+	sort.Slice(mentionees, func(i, j int) bool {
+		return mentionees[i].Index < mentionees[j].Index
+	})
+
+	units := utf16.Encode([]rune(text))
+	var rest []uint16
+	var users []MentionedUser
+	last := 0
+	for _, m := range mentionees {
+		end := m.Index + m.Length
+		if m.Index < last || end > len(units) {
+			continue
+		}
+		rest = append(rest, units[last:m.Index]...)
+		rest = append(rest, ' ')
+		last = end
+		if m.Type == linebot.MentionedTargetTypeAll || m.UserID == "" {
+			continue
+		}
+		name := string(utf16.Decode(units[m.Index:end]))
+		users = append(users, MentionedUser{
+			UserID: m.UserID,
+			Name:   strings.TrimPrefix(name, "@"),
+		})
+	}
+	rest = append(rest, units[last:]...)
+	return string(utf16.Decode(rest)), users
+}
@@ -0,0 +1,29 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/line/line-bot-sdk-go/v7/linebot"
+)
+
+func TestSplitMentions(t *testing.T) {
+	// the emoji takes two UTF-16 code units, shifting the second mention
+	text := "add @Niki 😴 @Luq telat"
+	mention := &linebot.Mention{Mentionees: []*linebot.Mentionee{
+		{Index: 13, Length: 4, Type: linebot.MentionedTargetTypeUser, UserID: "U2"},
+		{Index: 4, Length: 5, Type: linebot.MentionedTargetTypeUser, UserID: "U1"},
+	}}
+	rest, users := SplitMentions(text, mention)
+	if rest != "add   😴   telat" {
+		t.Errorf("rest: got %q", rest)
+	}
+	want := []MentionedUser{{UserID: "U1", Name: "Niki"}, {UserID: "U2", Name: "Luq"}}
+	if len(users) != len(want) {
+		t.Fatalf("got %d users, want %d", len(users), len(want))
+	}
+	for i := range want {
+		if users[i] != want[i] {
+			t.Errorf("user %d: got %+v, want %+v", i, users[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package util
+
+import "testing"
+
+func TestSignAndVerify(t *testing.T) {
+	sig := Sign("secret", "chart:abc:1790000000")
+	if !Verify("secret", "chart:abc:1790000000", sig) {
+		t.Error("valid signature rejected")
+	}
+	if Verify("secret", "chart:abc:1790000001", sig) {
+		t.Error("signature accepted for another payload")
+	}
+	if Verify("other", "chart:abc:1790000000", sig) {
+		t.Error("signature accepted with another secret")
+	}
+}
@@ -0,0 +1,14 @@
+package util
+
+import "time"
+
+// Location loads a time zone by name, falling back to DEFAULT_TIMEZONE.
+func Location(name string) *time.Location {
+	if loc, err := time.LoadLocation(name); err == nil && name != "" {
+		return loc
+	}
+	if loc, err := time.LoadLocation(DEFAULT_TIMEZONE); err == nil {
+		return loc
+	}
+	return time.UTC
+}